@@ -0,0 +1,252 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/server/common/dynamicconfig"
+	"go.temporal.io/server/common/log"
+	"gopkg.in/yaml.v3"
+
+	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
+)
+
+// Name of the file in the --config dir holding the settings of the start command.
+const startConfigFile = "temporalite.yaml"
+
+// startConfig is the format of temporalite.yaml. Each field corresponds to the start
+// flag of the same name, and applies only when the flag is not set on the command
+// line or through its environment variable.
+//
+// Relative paths are resolved against the config dir, so that the file can be
+// checked into a repository along with the files it refers to.
+//
+// The file is also loaded as the Temporal server config of the "temporalite"
+// environment, so it can hold server settings such as global or services too.
+type startConfig struct {
+	Ephemeral           *bool                  `yaml:"ephemeral"`
+	Filename            *string                `yaml:"filename"`
+	VisibilityFilename  *string                `yaml:"visibilityFilename"`
+	VisibilityEphemeral *bool                  `yaml:"visibilityEphemeral"`
+	NoAutoMigrate       *bool                  `yaml:"noAutoMigrate"`
+	Namespaces          []string               `yaml:"namespaces"`
+	NamespaceConfig     *string                `yaml:"namespaceConfig"`
+	SearchAttributes    map[string]string      `yaml:"searchAttributes"`
+	Port                *int                   `yaml:"port"`
+	MetricsPort         *int                   `yaml:"metricsPort"`
+	UIPort              *int                   `yaml:"uiPort"`
+	Headless            *bool                  `yaml:"headless"`
+	IP                  *string                `yaml:"ip"`
+	UIIP                *string                `yaml:"uiIp"`
+	UICodecEndpoint     *string                `yaml:"uiCodecEndpoint"`
+	UIFailurePolicy     *string                `yaml:"uiFailurePolicy"`
+	LogFormat           *string                `yaml:"logFormat"`
+	LogLevel            *string                `yaml:"logLevel"`
+	HistoryShards       *int                   `yaml:"historyShards"`
+	ArchivalDir         *string                `yaml:"archivalDir"`
+	AutoVacuum          *time.Duration         `yaml:"autoVacuum"`
+	SQLitePragmas       map[string]string      `yaml:"sqlitePragmas"`
+	DynamicConfig       map[string]interface{} `yaml:"dynamicConfig"`
+	DynamicConfigFile   *string                `yaml:"dynamicConfigFile"`
+}
+
+// loadStartConfig reads temporalite.yaml from the config dir. It returns nil if the
+// dir has no such file.
+func loadStartConfig(dir string) (*startConfig, error) {
+	path := filepath.Join(dir, startConfigFile)
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var cfg startConfig
+	if err := yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	for _, p := range []*string{cfg.Filename, cfg.VisibilityFilename, cfg.NamespaceConfig, cfg.ArchivalDir, cfg.DynamicConfigFile} {
+		if p != nil && *p != "" && !filepath.IsAbs(*p) {
+			*p = filepath.Join(dir, *p)
+		}
+	}
+	return &cfg, nil
+}
+
+// apply sets the flags of the start command that were set neither on the command
+// line nor through the environment to the values of the file.
+//
+// Dynamic config values cannot go through flags since they are JSON, which may
+// contain commas, so they are returned by dynamicConfigValues instead.
+func (cfg *startConfig) apply(c *cli.Context) error {
+	// Mutually exclusive flags set explicitly also take precedence over their
+	// counterpart in the file
+	exclusive := map[string]string{
+		ephemeralFlag:           dbPathFlag,
+		dbPathFlag:              ephemeralFlag,
+		visibilityEphemeralFlag: visibilityDBPathFlag,
+		visibilityDBPathFlag:    visibilityEphemeralFlag,
+	}
+	explicit := make(map[string]bool, len(exclusive))
+	for name := range exclusive {
+		explicit[name] = c.IsSet(name)
+	}
+
+	set := func(name string, values ...string) error {
+		if c.IsSet(name) || explicit[exclusive[name]] {
+			return nil
+		}
+		for _, v := range values {
+			if err := c.Set(name, v); err != nil {
+				return fmt.Errorf("bad value %q for %q in %s: %w", v, name, startConfigFile, err)
+			}
+		}
+		return nil
+	}
+	setBool := func(name string, v *bool) error {
+		// The start flags are all false by default, and setting them to false
+		// would make ephemeral: false conflict with filename
+		if v == nil || !*v {
+			return nil
+		}
+		return set(name, "true")
+	}
+	setString := func(name string, v *string) error {
+		if v == nil {
+			return nil
+		}
+		return set(name, *v)
+	}
+	setInt := func(name string, v *int) error {
+		if v == nil {
+			return nil
+		}
+		return set(name, strconv.Itoa(*v))
+	}
+	setMap := func(name string, m map[string]string) error {
+		values := make([]string, 0, len(m))
+		for k, v := range m {
+			values = append(values, k+"="+v)
+		}
+		sort.Strings(values)
+		return set(name, values...)
+	}
+
+	var autoVacuum *string
+	if cfg.AutoVacuum != nil {
+		s := cfg.AutoVacuum.String()
+		autoVacuum = &s
+	}
+
+	for _, err := range []error{
+		setBool(ephemeralFlag, cfg.Ephemeral),
+		setString(dbPathFlag, cfg.Filename),
+		setString(visibilityDBPathFlag, cfg.VisibilityFilename),
+		setBool(visibilityEphemeralFlag, cfg.VisibilityEphemeral),
+		setBool(noAutoMigrateFlag, cfg.NoAutoMigrate),
+		set(namespaceFlag, cfg.Namespaces...),
+		setString(namespaceConfigFlag, cfg.NamespaceConfig),
+		setMap(searchAttributeFlag, cfg.SearchAttributes),
+		setInt(portFlag, cfg.Port),
+		setInt(metricsPortFlag, cfg.MetricsPort),
+		setInt(uiPortFlag, cfg.UIPort),
+		setBool(headlessFlag, cfg.Headless),
+		setString(ipFlag, cfg.IP),
+		setString(uiIPFlag, cfg.UIIP),
+		setString(uiCodecEndpointFlag, cfg.UICodecEndpoint),
+		setString(uiFailurePolicyFlag, cfg.UIFailurePolicy),
+		setString(logFormatFlag, cfg.LogFormat),
+		setString(logLevelFlag, cfg.LogLevel),
+		setInt(historyShardsFlag, cfg.HistoryShards),
+		setString(archivalDirFlag, cfg.ArchivalDir),
+		setString(autoVacuumFlag, autoVacuum),
+		setMap(pragmaFlag, cfg.SQLitePragmas),
+		setString(dynamicConfigFileFlag, cfg.DynamicConfigFile),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dynamicConfigValues returns the dynamic config values of the file.
+func (cfg *startConfig) dynamicConfigValues() (map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue, error) {
+	values := make([]string, 0, len(cfg.DynamicConfig))
+	for k, v := range cfg.DynamicConfig {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for dynamic config key %q in %s: %w", k, startConfigFile, err)
+		}
+		values = append(values, k+"="+string(b))
+	}
+	return getDynamicConfigValues(values)
+}
+
+func newConfigCommand(defaultCfg *liteconfig.Config) *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect the server configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name: "print",
+				Usage: "Print the effective configuration of a server started with the same flags, with secrets redacted\n\n" +
+					"The database is not modified. Ports chosen by the system differ from one run to the next.",
+				ArgsUsage: " ",
+				Flags: append(newStartFlags(defaultCfg), &cli.StringFlag{
+					Name:    outputFlag,
+					Aliases: []string{"o"},
+					Value:   "yaml",
+					Usage:   `output format: "yaml" or "json"`,
+				}),
+				Before: func(c *cli.Context) error {
+					if c.Args().Len() > 0 {
+						return cli.Exit("ERROR: config print command doesn't support arguments.", 1)
+					}
+					if format := c.String(outputFlag); format != "yaml" && format != "json" {
+						return cli.Exit(fmt.Sprintf("ERROR: unknown output format %q", format), 1)
+					}
+					return validateStartFlags(c)
+				},
+				Action: func(c *cli.Context) error {
+					opts, err := newServerOptions(c)
+					if err != nil {
+						return err
+					}
+					// Keep the output parsable
+					opts = append(opts, temporalite.WithLogger(log.NewNoopLogger()))
+
+					cfg, err := temporalite.DescribeConfig(opts...)
+					if err != nil {
+						return err
+					}
+
+					if c.String(outputFlag) == "json" {
+						enc := json.NewEncoder(c.App.Writer)
+						enc.SetIndent("", "  ")
+						return enc.Encode(cfg)
+					}
+					enc := yaml.NewEncoder(c.App.Writer)
+					enc.SetIndent(2)
+					if err := enc.Encode(cfg); err != nil {
+						return err
+					}
+					return enc.Close()
+				},
+			},
+		},
+	}
+}
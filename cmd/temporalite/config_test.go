@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/server/common/dynamicconfig"
+)
+
+const testStartConfig = `
+filename: data/temporalite.db
+namespaces: [foo, bar]
+searchAttributes:
+  CustomerID: Keyword
+port: 10000
+logFormat: pretty
+logLevel: warn
+autoVacuum: 1h
+sqlitePragmas:
+  journal_mode: wal
+dynamicConfig:
+  frontend.enableSchedules: true
+  limit.maxIDLength: 500
+`
+
+func TestStartConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, startConfigFile), []byte(testStartConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	var start *cli.Command
+	for _, cmd := range temporaliteCLI.Commands {
+		if cmd.Name == "start" {
+			start = cmd
+		}
+	}
+
+	var (
+		dbPath           string
+		ephemeral        bool
+		namespaces       []string
+		searchAttributes []string
+		port             int
+		logFormat        string
+		logLevel         string
+		autoVacuum       time.Duration
+		pragmas          []string
+	)
+	start.Action = func(c *cli.Context) error {
+		dbPath = c.String(dbPathFlag)
+		ephemeral = c.Bool(ephemeralFlag)
+		namespaces = c.StringSlice(namespaceFlag)
+		searchAttributes = c.StringSlice(searchAttributeFlag)
+		port = c.Int(portFlag)
+		logFormat = c.String(logFormatFlag)
+		logLevel = c.String(logLevelFlag)
+		autoVacuum = c.Duration(autoVacuumFlag)
+		pragmas = c.StringSlice(pragmaFlag)
+		return nil
+	}
+
+	t.Setenv("TEMPORALITE_LOG_LEVEL", "debug")
+	if err := temporaliteCLI.Run([]string{"temporalite", "start", "-c", dir, "--port", "20000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Relative paths are resolved against the config dir
+	if expected := filepath.Join(dir, "data", "temporalite.db"); dbPath != expected {
+		t.Errorf("expected filename %q, got %q", expected, dbPath)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"foo", "bar"}) {
+		t.Errorf("expected namespaces from file, got %v", namespaces)
+	}
+	if !reflect.DeepEqual(searchAttributes, []string{"CustomerID=Keyword"}) {
+		t.Errorf("expected search attributes from file, got %v", searchAttributes)
+	}
+	if !reflect.DeepEqual(pragmas, []string{"journal_mode=wal"}) {
+		t.Errorf("expected pragmas from file, got %v", pragmas)
+	}
+	if logFormat != "pretty" {
+		t.Errorf("expected log format from file, got %q", logFormat)
+	}
+	if autoVacuum != time.Hour {
+		t.Errorf("expected auto-vacuum interval from file, got %s", autoVacuum)
+	}
+	// Flags and environment variables take precedence over the file
+	if port != 20000 {
+		t.Errorf("expected port from flag, got %d", port)
+	}
+	if logLevel != "debug" {
+		t.Errorf("expected log level from environment, got %q", logLevel)
+	}
+
+	// The file's filename does not conflict with --ephemeral
+	if err := temporaliteCLI.Run([]string{"temporalite", "start", "-c", dir, "--ephemeral"}); err != nil {
+		t.Fatal(err)
+	}
+	if !ephemeral {
+		t.Error("expected ephemeral from flag")
+	}
+	if port != 10000 {
+		t.Errorf("expected port from file, got %d", port)
+	}
+}
+
+func TestStartConfigFileDynamicConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, startConfigFile), []byte(testStartConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := loadStartConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := cfg.dynamicConfigValues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue{
+		dynamicconfig.FrontendEnableSchedules: {{Value: true}},
+		dynamicconfig.MaxIDLengthLimit:        {{Value: float64(500)}},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected %v, got %v", expected, values)
+	}
+
+	// A missing file is not an error
+	if cfg, err := loadStartConfig(t.TempDir()); err != nil || cfg != nil {
+		t.Errorf("expected no config, got %v, %v", cfg, err)
+	}
+}
+
+func TestConfigPrint(t *testing.T) {
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+	var out bytes.Buffer
+	temporaliteCLI.Writer = &out
+
+	if err := temporaliteCLI.Run([]string{
+		"temporalite", "config", "print", "-o", "json",
+		"--ephemeral", "--headless", "--port", "20000",
+		"--dynamic-config-value", "limit.maxIDLength=500",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg struct {
+		FrontendHostPort string
+		Server           struct {
+			Services map[string]interface{}
+		}
+		DynamicConfig map[string][]struct {
+			Value interface{}
+		}
+	}
+	if err := json.Unmarshal(out.Bytes(), &cfg); err != nil {
+		t.Fatalf("unable to parse output %s: %v", out.String(), err)
+	}
+	if cfg.FrontendHostPort != "127.0.0.1:20000" {
+		t.Errorf("unexpected frontend address %q", cfg.FrontendHostPort)
+	}
+	if len(cfg.Server.Services) != 4 {
+		t.Errorf("unexpected services %v", cfg.Server.Services)
+	}
+	if values := cfg.DynamicConfig["limit.maxIDLength"]; len(values) != 1 || values[0].Value != float64(500) {
+		t.Errorf("unexpected dynamic config %v", cfg.DynamicConfig)
+	}
+
+	// The database file is not created
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+	if err := temporaliteCLI.Run([]string{"temporalite", "config", "print", "--filename", dbPath, "--headless"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Errorf("expected database file not to exist, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+const (
+	outputFlag = "output"
+	inputFlag  = "input"
+)
+
+func newDBCommand(defaultCfg *liteconfig.Config) *cli.Command {
+	dbPath := &cli.StringFlag{
+		Name:    dbPathFlag,
+		Aliases: []string{"f"},
+		Value:   defaultCfg.DatabaseFilePath,
+		Usage:   "file in which Temporal state is persisted",
+	}
+
+	return &cli.Command{
+		Name:  "db",
+		Usage: "Manage Temporalite database files",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "backup",
+				Usage:     "Write a consistent copy of a database, even while the server is running",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					dbPath,
+					&cli.StringFlag{
+						Name:     outputFlag,
+						Aliases:  []string{"o"},
+						Usage:    `file to write the backup to, or "-" for stdout`,
+						Required: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if _, err := os.Stat(c.String(dbPathFlag)); err != nil {
+						return cli.Exit(fmt.Sprintf("ERROR: %s", err), 1)
+					}
+					cfg := litedb.NewFileConfig(c.String(dbPathFlag), true)
+
+					if c.String(outputFlag) == "-" {
+						return litedb.Backup(c.Context, cfg, c.App.Writer)
+					}
+					if err := litedb.BackupToFile(c.Context, cfg, c.String(outputFlag)); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Backed up %s to %s\n", c.String(dbPathFlag), c.String(outputFlag))
+					return nil
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Replace a database with a backup; the server must be stopped",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					dbPath,
+					&cli.StringFlag{
+						Name:     inputFlag,
+						Aliases:  []string{"i"},
+						Usage:    "backup file to restore",
+						Required: true,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if err := litedb.Restore(c.String(inputFlag), c.String(dbPathFlag)); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Restored %s from %s\n", c.String(dbPathFlag), c.String(inputFlag))
+					return nil
+				},
+			},
+			{
+				Name:      "compact",
+				Usage:     "Rebuild a database to return unused space to the file system; the server must be stopped",
+				ArgsUsage: " ",
+				Flags:     []cli.Flag{dbPath},
+				Action: func(c *cli.Context) error {
+					path := c.String(dbPathFlag)
+					before, err := os.Stat(path)
+					if err != nil {
+						return cli.Exit(fmt.Sprintf("ERROR: %s", err), 1)
+					}
+					if err := litedb.Compact(litedb.NewFileConfig(path, false)); err != nil {
+						return err
+					}
+					after, err := os.Stat(path)
+					if err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Compacted %s from %d to %d bytes\n", path, before.Size(), after.Size())
+					return nil
+				},
+			},
+			{
+				Name:      "inspect",
+				Usage:     "Report the schema version, namespaces, workflows, task queues, shards and size of a database",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					dbPath,
+					&cli.StringFlag{
+						Name:        visibilityDBPathFlag,
+						Usage:       "file in which visibility records are persisted",
+						DefaultText: "same as --" + dbPathFlag,
+					},
+					&cli.StringFlag{
+						Name:    outputFlag,
+						Aliases: []string{"o"},
+						Value:   "text",
+						Usage:   `output format: "text" or "json"`,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					format := c.String(outputFlag)
+					if format != "text" && format != "json" {
+						return cli.Exit(fmt.Sprintf("ERROR: unknown output format %q", format), 1)
+					}
+					visibilityPath := c.String(dbPathFlag)
+					if c.IsSet(visibilityDBPathFlag) {
+						visibilityPath = c.String(visibilityDBPathFlag)
+					}
+					for _, path := range []string{c.String(dbPathFlag), visibilityPath} {
+						if _, err := os.Stat(path); err != nil {
+							return cli.Exit(fmt.Sprintf("ERROR: %s", err), 1)
+						}
+					}
+
+					inspection, err := litedb.Inspect(liteconfig.ClusterName, c.String(dbPathFlag), visibilityPath)
+					if err != nil {
+						return err
+					}
+					if format == "json" {
+						enc := json.NewEncoder(c.App.Writer)
+						enc.SetIndent("", "  ")
+						return enc.Encode(inspection)
+					}
+					return printInspection(c.App.Writer, inspection)
+				},
+			},
+		},
+	}
+}
+
+func printInspection(out io.Writer, inspection *litedb.Inspection) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "SCHEMA\tVERSION")
+	var components []string
+	for component := range inspection.SchemaVersions {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", component, inspection.SchemaVersions[component])
+	}
+
+	_, _ = fmt.Fprintln(w, "\nFILE\tSIZE\tWAL SIZE")
+	for _, f := range inspection.Files {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%d\n", f.Path, f.Size, f.WALSize)
+	}
+
+	_, _ = fmt.Fprintln(w, "\nNAMESPACE\tSTATE\tWORKFLOWS")
+	for _, ns := range inspection.Namespaces {
+		name := ns.Name
+		if name == "" {
+			name = "(deleted " + ns.ID + ")"
+		}
+		var statuses []string
+		for status := range ns.Workflows {
+			statuses = append(statuses, status)
+		}
+		sort.Strings(statuses)
+		var workflows []string
+		for _, status := range statuses {
+			workflows = append(workflows, fmt.Sprintf("%s=%d", status, ns.Workflows[status]))
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", name, ns.State, strings.Join(workflows, " "))
+	}
+
+	_, _ = fmt.Fprintln(w, "\nTASK QUEUE\tNAMESPACE\tTYPE\tKIND\tBACKLOG")
+	for _, q := range inspection.TaskQueues {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\n", q.Name, q.Namespace, q.Type, q.Kind, q.Backlog)
+	}
+
+	_, _ = fmt.Fprintln(w, "\nSHARD\tRANGE ID\tOWNER\tUPDATED")
+	for _, shard := range inspection.Shards {
+		var updated string
+		if shard.UpdateTime != nil {
+			updated = shard.UpdateTime.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", shard.ID, shard.RangeID, shard.Owner, updated)
+	}
+
+	return w.Flush()
+}
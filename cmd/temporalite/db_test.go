@@ -0,0 +1,259 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log"
+
+	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+func TestDBBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		dbPath       = filepath.Join(dir, "temporalite.db")
+		backupPath   = filepath.Join(dir, "backup.db")
+		restoredPath = filepath.Join(dir, "restored.db")
+	)
+
+	// Creating a server sets up the database schema
+	if _, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(dbPath), temporalite.WithDynamicPorts()); err != nil {
+		t.Fatal(err)
+	}
+
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "backup", "-f", dbPath, "-o", backupPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "backup", "-f", dbPath, "-o", backupPath}); err == nil {
+		t.Error("expected error when backup file already exists")
+	}
+
+	if err := os.WriteFile(restoredPath, []byte("not a database"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "restore", "-f", restoredPath, "-i", backupPath}); err != nil {
+		t.Fatal(err)
+	}
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "restore", "-f", dbPath, "-i", restoredPath + "-missing"}); err == nil {
+		t.Error("expected error when backup file does not exist")
+	}
+
+	// A database cannot be restored while a server uses it
+	s, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(dbPath), temporalite.WithDynamicPorts(), temporalite.WithLogger(log.NewNoopLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = temporaliteCLI.Run([]string{"temporalite", "db", "restore", "-f", dbPath, "-i", backupPath})
+	s.Stop()
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected error when restoring the database of a running server, got %v", err)
+	}
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "restore", "-f", dbPath, "-i", backupPath}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restored database can be used by a new server
+	if _, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(restoredPath), temporalite.WithDynamicPorts(), temporalite.WithAutoMigrateDisabled()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDBInspect(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := temporalite.NewServer(
+		temporalite.WithDatabaseFilePath(dbPath),
+		temporalite.WithNamespaces("default"),
+		temporalite.WithDynamicPorts(),
+		temporalite.WithLogger(log.NewNoopLogger()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.NewClient(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without a worker, the first workflow task stays in the task queue backlog
+	if _, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{TaskQueue: "inspect-test"}, "Example"); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	// The task is added to the task queue asynchronously, and would be lost if the
+	// server stopped first
+	for {
+		inspection, err := litedb.Inspect(liteconfig.ClusterName, dbPath, dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inspectTestBacklog(inspection) > 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("task was not added to the inspect-test task queue")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	s.Stop()
+
+	run := func(args ...string) (string, error) {
+		var out bytes.Buffer
+		temporaliteCLI := buildCLI()
+		temporaliteCLI.Writer = &out
+		temporaliteCLI.ErrWriter = io.Discard
+		// Don't call os.Exit
+		temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+		err := temporaliteCLI.Run(append([]string{"temporalite", "db", "inspect", "-f", dbPath}, args...))
+		return out.String(), err
+	}
+
+	out, err := run("--output", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var inspection litedb.Inspection
+	if err := json.Unmarshal([]byte(out), &inspection); err != nil {
+		t.Fatalf("unable to decode %q: %s", out, err)
+	}
+	if len(inspection.SchemaVersions) == 0 {
+		t.Error("expected schema versions")
+	}
+	if len(inspection.Files) != 1 || inspection.Files[0].Size == 0 {
+		t.Errorf("expected size of database file, got %+v", inspection.Files)
+	}
+	if len(inspection.Shards) == 0 {
+		t.Error("expected shards")
+	}
+	var running int64
+	for _, ns := range inspection.Namespaces {
+		if ns.Name == "default" {
+			running = ns.Workflows["Running"]
+		}
+	}
+	if running != 1 {
+		t.Errorf("expected 1 running workflow in default namespace, got %+v", inspection.Namespaces)
+	}
+	if inspectTestBacklog(&inspection) == 0 {
+		t.Errorf("expected backlog in inspect-test task queue, got %+v", inspection.TaskQueues)
+	}
+
+	out, err = run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"temporal_visibility", "default", "Running=1", "inspect-test"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, out)
+		}
+	}
+
+	if _, err := run("--output", "yaml"); err == nil {
+		t.Error("expected error for unknown output format")
+	}
+}
+
+// inspectTestBacklog returns the number of tasks in the inspect-test task queue.
+func inspectTestBacklog(inspection *litedb.Inspection) int64 {
+	// The task may have been written to any partition of the task queue
+	var backlog int64
+	for _, q := range inspection.TaskQueues {
+		if strings.Contains(q.Name, "inspect-test") && q.Namespace == "default" {
+			backlog += q.Backlog
+		}
+	}
+	return backlog
+}
+
+func TestDBCompact(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+
+	// Creating a server sets up the database schema
+	if _, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(dbPath), temporalite.WithDynamicPorts(), temporalite.WithLogger(log.NewNoopLogger())); err != nil {
+		t.Fatal(err)
+	}
+	// Deleted rows leave free pages behind
+	if err := litedb.Exec(litedb.NewFileConfig(dbPath, false),
+		"CREATE TABLE junk (data BLOB)",
+		"INSERT INTO junk WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n LIMIT 4096) SELECT randomblob(1024) FROM n",
+		"DROP TABLE junk",
+	); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	temporaliteCLI := buildCLI()
+	temporaliteCLI.ErrWriter = io.Discard
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "compact", "-f", dbPath}); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Size() >= before.Size()-4<<20 {
+		t.Errorf("expected database to shrink by at least 4MiB, got %d bytes from %d", after.Size(), before.Size())
+	}
+
+	if err := temporaliteCLI.Run([]string{"temporalite", "db", "compact", "-f", dbPath + "-missing"}); err == nil {
+		t.Error("expected error when database does not exist")
+	}
+
+	// A database cannot be compacted while a server uses it
+	s, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(dbPath), temporalite.WithDynamicPorts(), temporalite.WithLogger(log.NewNoopLogger()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	err = temporaliteCLI.Run([]string{"temporalite", "db", "compact", "-f", dbPath})
+	s.Stop()
+	if err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected error when compacting the database of a running server, got %v", err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"strings"
 
 	"github.com/urfave/cli/v2"
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/server/common/config"
 	"go.temporal.io/server/common/dynamicconfig"
 	"go.temporal.io/server/common/headers"
@@ -34,23 +35,42 @@ import (
 const uiServerModule = "github.com/temporalio/ui-server/v2"
 
 const (
-	ephemeralFlag          = "ephemeral"
-	dbPathFlag             = "filename"
-	portFlag               = "port"
-	metricsPortFlag        = "metrics-port"
-	uiPortFlag             = "ui-port"
-	headlessFlag           = "headless"
-	ipFlag                 = "ip"
-	uiIPFlag               = "ui-ip"
-	uiCodecEndpointFlag    = "ui-codec-endpoint"
-	logFormatFlag          = "log-format"
-	logLevelFlag           = "log-level"
-	namespaceFlag          = "namespace"
-	pragmaFlag             = "sqlite-pragma"
-	configFlag             = "config"
-	dynamicConfigValueFlag = "dynamic-config-value"
+	ephemeralFlag           = "ephemeral"
+	dbPathFlag              = "filename"
+	visibilityDBPathFlag    = "visibility-filename"
+	visibilityEphemeralFlag = "visibility-ephemeral"
+	noAutoMigrateFlag       = "no-auto-migrate"
+	portFlag                = "port"
+	metricsPortFlag         = "metrics-port"
+	uiPortFlag              = "ui-port"
+	headlessFlag            = "headless"
+	ipFlag                  = "ip"
+	uiIPFlag                = "ui-ip"
+	uiCodecEndpointFlag     = "ui-codec-endpoint"
+	uiFailurePolicyFlag     = "ui-failure-policy"
+	logFormatFlag           = "log-format"
+	logLevelFlag            = "log-level"
+	namespaceFlag           = "namespace"
+	namespaceConfigFlag     = "namespace-config"
+	searchAttributeFlag     = "search-attribute"
+	historyShardsFlag       = "history-shards"
+	archivalDirFlag         = "archival-dir"
+	autoVacuumFlag          = "auto-vacuum"
+	pragmaFlag              = "sqlite-pragma"
+	configFlag              = "config"
+	dynamicConfigValueFlag  = "dynamic-config-value"
+	dynamicConfigFileFlag   = "dynamic-config-file"
 )
 
+// Prefix of the environment variables that can be used instead of start flags.
+const envVarPrefix = "TEMPORALITE_"
+
+// envVars returns the environment variable that sets the flag called name, such as
+// TEMPORALITE_DYNAMIC_CONFIG_VALUE for --dynamic-config-value.
+func envVars(name string) []string {
+	return []string{envVarPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))}
+}
+
 type uiConfig struct {
 	Host                string
 	Port                int
@@ -83,173 +103,23 @@ func buildCLI() *cli.App {
 			Name:      "start",
 			Usage:     "Start Temporal server",
 			ArgsUsage: " ",
-			Flags: []cli.Flag{
-				&cli.BoolFlag{
-					Name:  ephemeralFlag,
-					Value: defaultCfg.Ephemeral,
-					Usage: "enable the in-memory storage driver **data will be lost on restart**",
-				},
-				&cli.StringFlag{
-					Name:    dbPathFlag,
-					Aliases: []string{"f"},
-					Value:   defaultCfg.DatabaseFilePath,
-					Usage:   "file in which to persist Temporal state",
-				},
-				&cli.StringSliceFlag{
-					Name:    namespaceFlag,
-					Aliases: []string{"n"},
-					Usage:   `specify namespaces that should be pre-created`,
-					EnvVars: nil,
-					Value:   nil,
-				},
-				&cli.IntFlag{
-					Name:    portFlag,
-					Aliases: []string{"p"},
-					Usage:   "port for the temporal-frontend GRPC service",
-					Value:   liteconfig.DefaultFrontendPort,
-				},
-				&cli.IntFlag{
-					Name:  metricsPortFlag,
-					Usage: "port for the metrics listener",
-					Value: liteconfig.DefaultMetricsPort,
-				},
-				&cli.IntFlag{
-					Name:        uiPortFlag,
-					Usage:       "port for the temporal web UI",
-					DefaultText: fmt.Sprintf("--port + 1000, eg. %d", liteconfig.DefaultFrontendPort+1000),
-				},
-				&cli.BoolFlag{
-					Name:  headlessFlag,
-					Usage: "disable the temporal web UI",
-				},
-				&cli.StringFlag{
-					Name:    ipFlag,
-					Usage:   `IPv4 address to bind the frontend service to instead of localhost`,
-					EnvVars: nil,
-					Value:   "127.0.0.1",
-				},
-				&cli.StringFlag{
-					Name:        uiIPFlag,
-					Usage:       `IPv4 address to bind the web UI to instead of localhost`,
-					DefaultText: "same as --ip (eg. 127.0.0.1)",
-				},
-				&cli.StringFlag{
-					Name:    uiCodecEndpointFlag,
-					Usage:   `UI Remote data converter HTTP endpoint`,
-					EnvVars: nil,
-				},
-				&cli.StringFlag{
-					Name:    logFormatFlag,
-					Usage:   `customize the log formatting (allowed: ["json" "pretty"])`,
-					EnvVars: nil,
-					Value:   "json",
-				},
-				&cli.StringFlag{
-					Name:    logLevelFlag,
-					Usage:   `customize the log level (allowed: ["debug" "info" "warn" "error" "fatal"])`,
-					EnvVars: nil,
-					Value:   "info",
-				},
-				&cli.StringSliceFlag{
-					Name:    pragmaFlag,
-					Aliases: []string{"sp"},
-					Usage:   fmt.Sprintf("specify sqlite pragma statements in pragma=value format (allowed: %q)", liteconfig.GetAllowedPragmas()),
-					EnvVars: nil,
-					Value:   nil,
-				},
-				&cli.StringFlag{
-					Name:    configFlag,
-					Aliases: []string{"c"},
-					Usage:   `config dir path`,
-					EnvVars: []string{config.EnvKeyConfigDir},
-					Value:   "",
-				},
-				&cli.StringSliceFlag{
-					Name:  dynamicConfigValueFlag,
-					Usage: `dynamic config value, as KEY=JSON_VALUE (meaning strings need quotes)`,
-				},
-			},
+			Flags:     newStartFlags(defaultCfg),
 			Before: func(c *cli.Context) error {
 				if c.Args().Len() > 0 {
 					return cli.Exit("ERROR: start command doesn't support arguments.", 1)
 				}
-				if c.IsSet(ephemeralFlag) && c.IsSet(dbPathFlag) {
-					return cli.Exit(fmt.Sprintf("ERROR: only one of %q or %q flags may be passed at a time", ephemeralFlag, dbPathFlag), 1)
+				if err := validateStartFlags(c); err != nil {
+					return err
 				}
-
 				// Make sure the default db path exists (user does not specify path explicitly)
 				if !c.IsSet(dbPathFlag) {
 					if err := os.MkdirAll(filepath.Dir(c.String(dbPathFlag)), os.ModePerm); err != nil {
 						return cli.Exit(err.Error(), 1)
 					}
 				}
-
-				switch c.String(logFormatFlag) {
-				case "json", "pretty", "noop":
-				default:
-					return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(logFormatFlag), logFormatFlag), 1)
-				}
-
-				switch c.String(logLevelFlag) {
-				case "debug", "info", "warn", "error", "fatal":
-				default:
-					return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(logLevelFlag), logLevelFlag), 1)
-				}
-
-				// Check that ip address is valid
-				if c.IsSet(ipFlag) && net.ParseIP(c.String(ipFlag)) == nil {
-					return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(ipFlag), ipFlag), 1)
-				}
-
-				if c.IsSet(configFlag) {
-					cfgPath := c.String(configFlag)
-					if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
-						return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q: file not found", c.String(configFlag), configFlag), 1)
-					}
-				}
-
 				return nil
 			},
 			Action: func(c *cli.Context) error {
-				var (
-					ip              = c.String(ipFlag)
-					serverPort      = c.Int(portFlag)
-					metricsPort     = c.Int(metricsPortFlag)
-					uiPort          = serverPort + 1000
-					uiIP            = ip
-					uiCodecEndpoint = ""
-				)
-
-				if c.IsSet(uiPortFlag) {
-					uiPort = c.Int(uiPortFlag)
-				}
-
-				if c.IsSet(uiIPFlag) {
-					uiIP = c.String(uiIPFlag)
-				}
-
-				if c.IsSet(uiCodecEndpointFlag) {
-					uiCodecEndpoint = c.String(uiCodecEndpointFlag)
-				}
-
-				pragmas, err := getPragmaMap(c.StringSlice(pragmaFlag))
-				if err != nil {
-					return err
-				}
-
-				baseConfig := &config.Config{}
-				if c.IsSet(configFlag) {
-					// Temporal server requires a couple of persistence config values to
-					// be explicitly set or the config loading fails. While these are the
-					// same values used internally, they are overridden later anyways,
-					// they are just here to pass validation.
-					baseConfig.Persistence.DefaultStore = liteconfig.PersistenceStoreName
-					baseConfig.Persistence.NumHistoryShards = 1
-					if err := config.Load("temporalite", c.String(configFlag), "", &baseConfig); err != nil {
-						return err
-					}
-				}
-
 				interruptChan := make(chan interface{}, 1)
 				go func() {
 					if doneChan := c.Done(); doneChan != nil {
@@ -261,84 +131,13 @@ func buildCLI() *cli.App {
 					}
 				}()
 
-				opts := []temporalite.ServerOption{
-					temporalite.WithDynamicPorts(),
-					temporalite.WithFrontendPort(serverPort),
-					temporalite.WithMetricsPort(metricsPort),
-					temporalite.WithFrontendIP(ip),
-					temporalite.WithDatabaseFilePath(c.String(dbPathFlag)),
-					temporalite.WithNamespaces(c.StringSlice(namespaceFlag)...),
-					temporalite.WithSQLitePragmas(pragmas),
-					temporalite.WithUpstreamOptions(
-						temporal.InterruptOn(interruptChan),
-					),
-					temporalite.WithBaseConfig(baseConfig),
-				}
-				if !c.Bool(headlessFlag) {
-					frontendAddr := fmt.Sprintf("%s:%d", ip, serverPort)
-					cfg := &uiConfig{
-						Host:                uiIP,
-						Port:                uiPort,
-						TemporalGRPCAddress: frontendAddr,
-						EnableUI:            true,
-						CodecEndpoint:       uiCodecEndpoint,
-					}
-
-					opt, err := newUIOption(cfg, c.String(configFlag))
-					if err != nil {
-						return err
-					}
-					if opt != nil {
-						opts = append(opts, opt)
-					}
-				}
-				if c.Bool(ephemeralFlag) {
-					opts = append(opts, temporalite.WithPersistenceDisabled())
-				}
-
-				var logger log.Logger
-				switch c.String(logFormatFlag) {
-				case "pretty":
-					lcfg := zap.NewDevelopmentConfig()
-					switch c.String(logLevelFlag) {
-					case "debug":
-						lcfg.Level.SetLevel(zap.DebugLevel)
-					case "info":
-						lcfg.Level.SetLevel(zap.InfoLevel)
-					case "warn":
-						lcfg.Level.SetLevel(zap.WarnLevel)
-					case "error":
-						lcfg.Level.SetLevel(zap.ErrorLevel)
-					case "fatal":
-						lcfg.Level.SetLevel(zap.FatalLevel)
-					}
-					lcfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-					l, err := lcfg.Build(
-						zap.WithCaller(false),
-						zap.AddStacktrace(zapcore.ErrorLevel),
-					)
-					if err != nil {
-						return err
-					}
-					logger = log.NewZapLogger(l)
-				case "noop":
-					logger = log.NewNoopLogger()
-				default:
-					logger = log.NewZapLogger(log.BuildZapLogger(log.Config{
-						Stdout:     true,
-						Level:      c.String(logLevelFlag),
-						OutputFile: "",
-					}))
-				}
-				opts = append(opts, temporalite.WithLogger(logger))
-
-				configVals, err := getDynamicConfigValues(c.StringSlice(dynamicConfigValueFlag))
+				opts, err := newServerOptions(c)
 				if err != nil {
 					return err
 				}
-				for k, v := range configVals {
-					opts = append(opts, temporalite.WithDynamicConfigValue(k, v))
-				}
+				opts = append(opts, temporalite.WithUpstreamOptions(
+					temporal.InterruptOn(interruptChan),
+				))
 
 				s, err := temporalite.NewServer(opts...)
 				if err != nil {
@@ -351,11 +150,415 @@ func buildCLI() *cli.App {
 				return cli.Exit("All services are stopped.", 0)
 			},
 		},
+		newDBCommand(defaultCfg),
+		newNamespaceCommand(defaultCfg),
+		newWorkflowCommand(defaultCfg),
+		newConfigCommand(defaultCfg),
 	}
 
 	return app
 }
 
+// newStartFlags returns the flags of the start command, which describe how the
+// server is set up.
+func newStartFlags(defaultCfg *liteconfig.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:    ephemeralFlag,
+			EnvVars: envVars(ephemeralFlag),
+			Value:   defaultCfg.Ephemeral,
+			Usage:   "enable the in-memory storage driver **data will be lost on restart**",
+		},
+		&cli.StringFlag{
+			Name:    dbPathFlag,
+			Aliases: []string{"f"},
+			EnvVars: envVars(dbPathFlag),
+			Value:   defaultCfg.DatabaseFilePath,
+			Usage:   "file in which to persist Temporal state",
+		},
+		&cli.StringFlag{
+			Name:    visibilityDBPathFlag,
+			EnvVars: envVars(visibilityDBPathFlag),
+			Usage:   "separate file in which to persist workflow visibility records",
+		},
+		&cli.BoolFlag{
+			Name:    visibilityEphemeralFlag,
+			EnvVars: envVars(visibilityEphemeralFlag),
+			Usage:   "keep workflow visibility records in memory **workflows will not be listed after restart**",
+		},
+		&cli.BoolFlag{
+			Name:    noAutoMigrateFlag,
+			EnvVars: envVars(noAutoMigrateFlag),
+			Usage:   "refuse to start instead of upgrading the schema of an existing database file",
+		},
+		&cli.StringSliceFlag{
+			Name:    namespaceFlag,
+			Aliases: []string{"n"},
+			EnvVars: envVars(namespaceFlag),
+			Usage:   `specify namespaces that should be pre-created`,
+			Value:   nil,
+		},
+		&cli.StringFlag{
+			Name:    namespaceConfigFlag,
+			EnvVars: envVars(namespaceConfigFlag),
+			Usage:   "YAML file describing namespaces to create on startup, and to update if they already exist",
+		},
+		&cli.StringSliceFlag{
+			Name:    searchAttributeFlag,
+			EnvVars: envVars(searchAttributeFlag),
+			Usage:   `custom search attribute to register on startup, as NAME=TYPE (allowed types: ["Text" "Keyword" "Int" "Double" "Bool" "Datetime" "KeywordList"])`,
+		},
+		&cli.IntFlag{
+			Name:    portFlag,
+			Aliases: []string{"p"},
+			EnvVars: envVars(portFlag),
+			Usage:   "port for the temporal-frontend GRPC service",
+			Value:   liteconfig.DefaultFrontendPort,
+		},
+		&cli.IntFlag{
+			Name:    metricsPortFlag,
+			EnvVars: envVars(metricsPortFlag),
+			Usage:   "port for the metrics listener",
+			Value:   liteconfig.DefaultMetricsPort,
+		},
+		&cli.IntFlag{
+			Name:        uiPortFlag,
+			EnvVars:     envVars(uiPortFlag),
+			Usage:       "port for the temporal web UI",
+			DefaultText: fmt.Sprintf("--port + 1000, eg. %d", liteconfig.DefaultFrontendPort+1000),
+		},
+		&cli.BoolFlag{
+			Name:    headlessFlag,
+			EnvVars: envVars(headlessFlag),
+			Usage:   "disable the temporal web UI",
+		},
+		&cli.StringFlag{
+			Name:    ipFlag,
+			EnvVars: envVars(ipFlag),
+			Usage:   `IPv4 address to bind the frontend service to instead of localhost`,
+			Value:   "127.0.0.1",
+		},
+		&cli.StringFlag{
+			Name:        uiIPFlag,
+			EnvVars:     envVars(uiIPFlag),
+			Usage:       `IPv4 address to bind the web UI to instead of localhost`,
+			DefaultText: "same as --ip (eg. 127.0.0.1)",
+		},
+		&cli.StringFlag{
+			Name:    uiCodecEndpointFlag,
+			EnvVars: envVars(uiCodecEndpointFlag),
+			Usage:   `UI Remote data converter HTTP endpoint`,
+		},
+		&cli.StringFlag{
+			Name:    uiFailurePolicyFlag,
+			EnvVars: envVars(uiFailurePolicyFlag),
+			Usage:   `action to take when the web UI cannot be started (allowed: ["fail-fast" "log-and-continue" "retry-port"])`,
+			Value:   "fail-fast",
+		},
+		&cli.StringFlag{
+			Name:    logFormatFlag,
+			EnvVars: envVars(logFormatFlag),
+			Usage:   `customize the log formatting (allowed: ["json" "pretty"])`,
+			Value:   "json",
+		},
+		&cli.StringFlag{
+			Name:    logLevelFlag,
+			EnvVars: envVars(logLevelFlag),
+			Usage:   `customize the log level (allowed: ["debug" "info" "warn" "error" "fatal"])`,
+			Value:   "info",
+		},
+		&cli.IntFlag{
+			Name:        historyShardsFlag,
+			EnvVars:     envVars(historyShardsFlag),
+			Usage:       "number of history shards, which cannot be changed once the database is created",
+			DefaultText: "1 for new databases",
+		},
+		&cli.StringFlag{
+			Name:    archivalDirFlag,
+			EnvVars: envVars(archivalDirFlag),
+			Usage:   "enable history and visibility archival to the specified directory",
+		},
+		&cli.DurationFlag{
+			Name:    autoVacuumFlag,
+			EnvVars: envVars(autoVacuumFlag),
+			Usage:   "interval at which space freed in the database is returned to the file system and the WAL is checkpointed",
+		},
+		&cli.StringSliceFlag{
+			Name:    pragmaFlag,
+			Aliases: []string{"sp"},
+			EnvVars: envVars(pragmaFlag),
+			Usage:   fmt.Sprintf("specify sqlite pragma statements in pragma=value format (allowed: %q)", liteconfig.GetAllowedPragmas()),
+			Value:   nil,
+		},
+		&cli.StringFlag{
+			Name:    configFlag,
+			Aliases: []string{"c"},
+			EnvVars: append(envVars(configFlag), config.EnvKeyConfigDir),
+			Usage:   `config dir path`,
+			Value:   "",
+		},
+		&cli.StringSliceFlag{
+			// The environment variable is read by dynamicConfigValueArgs, as the
+			// flag would split its value on the commas of JSON values
+			Name:  dynamicConfigValueFlag,
+			Usage: fmt.Sprintf(`dynamic config value, as KEY=JSON_VALUE (meaning strings need quotes) [$%s, one value per line]`, envVars(dynamicConfigValueFlag)[0]),
+		},
+		&cli.StringFlag{
+			Name:    dynamicConfigFileFlag,
+			EnvVars: envVars(dynamicConfigFileFlag),
+			Usage:   "dynamic config file, reloaded when it changes; --dynamic-config-value takes precedence over it",
+		},
+	}
+}
+
+// validateStartFlags applies the settings of temporalite.yaml and checks the
+// values of the start flags.
+func validateStartFlags(c *cli.Context) error {
+	if c.IsSet(configFlag) {
+		cfgPath := c.String(configFlag)
+		if _, err := os.Stat(cfgPath); os.IsNotExist(err) {
+			return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q: file not found", c.String(configFlag), configFlag), 1)
+		}
+		// Settings of temporalite.yaml apply to the flags that are not set
+		startCfg, err := loadStartConfig(cfgPath)
+		if err != nil {
+			return cli.Exit(err.Error(), 1)
+		}
+		if startCfg != nil {
+			if err := startCfg.apply(c); err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+		}
+	}
+
+	if c.IsSet(ephemeralFlag) && c.IsSet(dbPathFlag) {
+		return cli.Exit(fmt.Sprintf("ERROR: only one of %q or %q flags may be passed at a time", ephemeralFlag, dbPathFlag), 1)
+	}
+	if c.IsSet(visibilityEphemeralFlag) && c.IsSet(visibilityDBPathFlag) {
+		return cli.Exit(fmt.Sprintf("ERROR: only one of %q or %q flags may be passed at a time", visibilityEphemeralFlag, visibilityDBPathFlag), 1)
+	}
+
+	switch c.String(logFormatFlag) {
+	case "json", "pretty", "noop":
+	default:
+		return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(logFormatFlag), logFormatFlag), 1)
+	}
+
+	switch c.String(logLevelFlag) {
+	case "debug", "info", "warn", "error", "fatal":
+	default:
+		return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(logLevelFlag), logLevelFlag), 1)
+	}
+
+	if _, err := getUIFailurePolicy(c.String(uiFailurePolicyFlag)); err != nil {
+		return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(uiFailurePolicyFlag), uiFailurePolicyFlag), 1)
+	}
+
+	// Check that ip address is valid
+	if c.IsSet(ipFlag) && net.ParseIP(c.String(ipFlag)) == nil {
+		return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q", c.String(ipFlag), ipFlag), 1)
+	}
+
+	if c.IsSet(historyShardsFlag) && c.Int(historyShardsFlag) <= 0 {
+		return cli.Exit(fmt.Sprintf("bad value %d passed for flag %q: must be positive", c.Int(historyShardsFlag), historyShardsFlag), 1)
+	}
+
+	if c.IsSet(autoVacuumFlag) && c.Duration(autoVacuumFlag) <= 0 {
+		return cli.Exit(fmt.Sprintf("bad value %s passed for flag %q: must be positive", c.Duration(autoVacuumFlag), autoVacuumFlag), 1)
+	}
+
+	if _, err := getSearchAttributes(c.StringSlice(searchAttributeFlag)); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	if c.IsSet(namespaceConfigFlag) {
+		if _, err := loadNamespaceSpecs(c.String(namespaceConfigFlag)); err != nil {
+			return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q: %v", c.String(namespaceConfigFlag), namespaceConfigFlag, err), 1)
+		}
+	}
+
+	if c.IsSet(dynamicConfigFileFlag) {
+		if _, err := os.Stat(c.String(dynamicConfigFileFlag)); err != nil {
+			return cli.Exit(fmt.Sprintf("bad value %q passed for flag %q: %v", c.String(dynamicConfigFileFlag), dynamicConfigFileFlag, err), 1)
+		}
+	}
+
+	return nil
+}
+
+// newServerOptions returns the options of the server described by the start flags.
+func newServerOptions(c *cli.Context) ([]temporalite.ServerOption, error) {
+	var (
+		ip              = c.String(ipFlag)
+		serverPort      = c.Int(portFlag)
+		metricsPort     = c.Int(metricsPortFlag)
+		uiPort          = serverPort + 1000
+		uiIP            = ip
+		uiCodecEndpoint = ""
+	)
+
+	if c.IsSet(uiPortFlag) {
+		uiPort = c.Int(uiPortFlag)
+	}
+
+	if c.IsSet(uiIPFlag) {
+		uiIP = c.String(uiIPFlag)
+	}
+
+	if c.IsSet(uiCodecEndpointFlag) {
+		uiCodecEndpoint = c.String(uiCodecEndpointFlag)
+	}
+
+	pragmas, err := getPragmaMap(c.StringSlice(pragmaFlag))
+	if err != nil {
+		return nil, err
+	}
+
+	baseConfig := &config.Config{}
+	if c.IsSet(configFlag) {
+		// Temporal server requires a couple of persistence config values to
+		// be explicitly set or the config loading fails. While these are the
+		// same values used internally, they are overridden later anyways,
+		// they are just here to pass validation.
+		baseConfig.Persistence.DefaultStore = liteconfig.PersistenceStoreName
+		baseConfig.Persistence.NumHistoryShards = 1
+		if err := config.Load("temporalite", c.String(configFlag), "", &baseConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []temporalite.ServerOption{
+		temporalite.WithDynamicPorts(),
+		temporalite.WithFrontendPort(serverPort),
+		temporalite.WithMetricsPort(metricsPort),
+		temporalite.WithFrontendIP(ip),
+		temporalite.WithDatabaseFilePath(c.String(dbPathFlag)),
+		temporalite.WithNamespaces(c.StringSlice(namespaceFlag)...),
+		temporalite.WithSQLitePragmas(pragmas),
+		temporalite.WithBaseConfig(baseConfig),
+	}
+	if !c.Bool(headlessFlag) {
+		frontendAddr := fmt.Sprintf("%s:%d", ip, serverPort)
+		cfg := &uiConfig{
+			Host:                uiIP,
+			Port:                uiPort,
+			TemporalGRPCAddress: frontendAddr,
+			EnableUI:            true,
+			CodecEndpoint:       uiCodecEndpoint,
+		}
+
+		opt, err := newUIOption(cfg, c.String(configFlag))
+		if err != nil {
+			return nil, err
+		}
+		if opt != nil {
+			opts = append(opts, opt)
+		}
+
+		policy, err := getUIFailurePolicy(c.String(uiFailurePolicyFlag))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, temporalite.WithUIFailurePolicy(policy))
+	}
+	if c.Bool(ephemeralFlag) {
+		opts = append(opts, temporalite.WithPersistenceDisabled())
+	}
+	if c.IsSet(visibilityDBPathFlag) {
+		opts = append(opts, temporalite.WithVisibilityDatabaseFilePath(c.String(visibilityDBPathFlag)))
+	}
+	if c.Bool(visibilityEphemeralFlag) {
+		opts = append(opts, temporalite.WithVisibilityPersistenceDisabled())
+	}
+	if c.Bool(noAutoMigrateFlag) {
+		opts = append(opts, temporalite.WithAutoMigrateDisabled())
+	}
+	if c.IsSet(historyShardsFlag) {
+		opts = append(opts, temporalite.WithHistoryShards(int32(c.Int(historyShardsFlag))))
+	}
+	if c.IsSet(searchAttributeFlag) {
+		attributes, err := getSearchAttributes(c.StringSlice(searchAttributeFlag))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, temporalite.WithSearchAttributes(attributes))
+	}
+	if c.IsSet(namespaceConfigFlag) {
+		specs, err := loadNamespaceSpecs(c.String(namespaceConfigFlag))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, temporalite.WithNamespaceConfigs(specs))
+	}
+	if c.IsSet(archivalDirFlag) {
+		opts = append(opts, temporalite.WithArchival(c.String(archivalDirFlag)))
+	}
+	if c.IsSet(autoVacuumFlag) {
+		opts = append(opts, temporalite.WithMaintenance(c.Duration(autoVacuumFlag)))
+	}
+	if c.IsSet(dynamicConfigFileFlag) {
+		opts = append(opts, temporalite.WithDynamicConfigFile(c.String(dynamicConfigFileFlag), 0))
+	}
+
+	var logger log.Logger
+	switch c.String(logFormatFlag) {
+	case "pretty":
+		lcfg := zap.NewDevelopmentConfig()
+		switch c.String(logLevelFlag) {
+		case "debug":
+			lcfg.Level.SetLevel(zap.DebugLevel)
+		case "info":
+			lcfg.Level.SetLevel(zap.InfoLevel)
+		case "warn":
+			lcfg.Level.SetLevel(zap.WarnLevel)
+		case "error":
+			lcfg.Level.SetLevel(zap.ErrorLevel)
+		case "fatal":
+			lcfg.Level.SetLevel(zap.FatalLevel)
+		}
+		lcfg.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		l, err := lcfg.Build(
+			zap.WithCaller(false),
+			zap.AddStacktrace(zapcore.ErrorLevel),
+		)
+		if err != nil {
+			return nil, err
+		}
+		logger = log.NewZapLogger(l)
+	case "noop":
+		logger = log.NewNoopLogger()
+	default:
+		logger = log.NewZapLogger(log.BuildZapLogger(log.Config{
+			Stdout:     true,
+			Level:      c.String(logLevelFlag),
+			OutputFile: "",
+		}))
+	}
+	opts = append(opts, temporalite.WithLogger(logger))
+
+	configArgs, configArgsSet := dynamicConfigValueArgs(c)
+	configVals, err := getDynamicConfigValues(configArgs)
+	if err != nil {
+		return nil, err
+	}
+	if c.IsSet(configFlag) && !configArgsSet {
+		startCfg, err := loadStartConfig(c.String(configFlag))
+		if err != nil {
+			return nil, err
+		}
+		if startCfg != nil {
+			if configVals, err = startCfg.dynamicConfigValues(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for k, v := range configVals {
+		opts = append(opts, temporalite.WithDynamicConfigValue(k, v))
+	}
+
+	return opts, nil
+}
+
 func getPragmaMap(input []string) (map[string]string, error) {
 	result := make(map[string]string)
 	for _, pragma := range input {
@@ -368,6 +571,54 @@ func getPragmaMap(input []string) (map[string]string, error) {
 	return result, nil
 }
 
+func getSearchAttributes(input []string) (map[string]enums.IndexedValueType, error) {
+	result := make(map[string]enums.IndexedValueType)
+	for _, attribute := range input {
+		vals := strings.Split(attribute, "=")
+		if len(vals) != 2 {
+			return nil, fmt.Errorf("ERROR: search attributes must be in NAME=TYPE format, got %q", attribute)
+		}
+		t, err := parseSearchAttributeType(vals[1])
+		if err != nil {
+			return nil, fmt.Errorf("ERROR: search attribute %q: %w", vals[0], err)
+		}
+		result[vals[0]] = t
+	}
+	return result, nil
+}
+
+func getUIFailurePolicy(input string) (temporalite.UIFailurePolicy, error) {
+	switch input {
+	case "fail-fast":
+		return temporalite.UIFailFast, nil
+	case "log-and-continue":
+		return temporalite.UILogAndContinue, nil
+	case "retry-port":
+		return temporalite.UIRetryOnAnotherPort, nil
+	}
+	return 0, fmt.Errorf("unsupported UI failure policy %q", input)
+}
+
+// dynamicConfigValueArgs returns the values of the dynamic config value flags or,
+// when there are none, the lines of the corresponding environment variable, and
+// whether any was set.
+func dynamicConfigValueArgs(c *cli.Context) ([]string, bool) {
+	if c.IsSet(dynamicConfigValueFlag) {
+		return c.StringSlice(dynamicConfigValueFlag), true
+	}
+	env, ok := os.LookupEnv(envVars(dynamicConfigValueFlag)[0])
+	if !ok {
+		return nil, false
+	}
+	var args []string
+	for _, line := range strings.Split(env, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			args = append(args, line)
+		}
+	}
+	return args, true
+}
+
 func getDynamicConfigValues(input []string) (map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue, error) {
 	ret := make(map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue, len(input))
 	for _, keyValStr := range input {
@@ -39,8 +39,12 @@ import (
 	"github.com/urfave/cli/v2"
 	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/dynamicconfig"
+	sqliteplugin "go.temporal.io/server/common/persistence/sql/sqlplugin/sqlite"
 
 	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
 )
 
 func TestGetDynamicConfigValues(t *testing.T) {
@@ -78,6 +82,146 @@ func TestGetDynamicConfigValues(t *testing.T) {
 	)
 }
 
+func TestGetSearchAttributes(t *testing.T) {
+	actual, err := getSearchAttributes([]string{"CustomerId=Keyword", "Total=double", "Tags=KeywordList"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]enums.IndexedValueType{
+		"CustomerId": enums.INDEXED_VALUE_TYPE_KEYWORD,
+		"Total":      enums.INDEXED_VALUE_TYPE_DOUBLE,
+		"Tags":       enums.INDEXED_VALUE_TYPE_KEYWORD_LIST,
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("not equal, expected - actual: %v - %v", expected, actual)
+	}
+
+	for _, v := range []string{"CustomerId", "CustomerId=", "CustomerId=Uuid"} {
+		if _, err := getSearchAttributes([]string{v}); err == nil {
+			t.Errorf("expected error for %v", v)
+		}
+	}
+}
+
+func TestStartEnvVars(t *testing.T) {
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	var start *cli.Command
+	for _, cmd := range temporaliteCLI.Commands {
+		if cmd.Name == "start" {
+			start = cmd
+		}
+	}
+	for _, flag := range start.Flags {
+		name := flag.Names()[0]
+		if name == dynamicConfigValueFlag {
+			// Read by dynamicConfigValueArgs
+			continue
+		}
+		envVars := flag.(interface{ GetEnvVars() []string }).GetEnvVars()
+		if len(envVars) == 0 || envVars[0] != "TEMPORALITE_"+strings.ToUpper(strings.ReplaceAll(name, "-", "_")) {
+			t.Errorf("unexpected environment variables for flag %q: %v", name, envVars)
+		}
+	}
+
+	var (
+		port       int
+		namespaces []string
+		ephemeral  bool
+		logLevel   string
+	)
+	start.Action = func(c *cli.Context) error {
+		port = c.Int(portFlag)
+		namespaces = c.StringSlice(namespaceFlag)
+		ephemeral = c.Bool(ephemeralFlag)
+		logLevel = c.String(logLevelFlag)
+		return nil
+	}
+
+	t.Setenv("TEMPORALITE_PORT", "10000")
+	t.Setenv("TEMPORALITE_NAMESPACE", "foo,bar")
+	t.Setenv("TEMPORALITE_EPHEMERAL", "true")
+	t.Setenv("TEMPORALITE_LOG_LEVEL", "debug")
+	if err := temporaliteCLI.Run([]string{"temporalite", "start", "--port", "20000", "--namespace", "baz"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags take precedence over environment variables, which take precedence over defaults
+	if port != 20000 {
+		t.Errorf("expected port from flag, got %d", port)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"baz"}) {
+		t.Errorf("expected namespaces from flag, got %v", namespaces)
+	}
+	if !ephemeral {
+		t.Error("expected ephemeral from environment")
+	}
+	if logLevel != "debug" {
+		t.Errorf("expected log level from environment, got %q", logLevel)
+	}
+
+	if err := temporaliteCLI.Run([]string{"temporalite", "start"}); err != nil {
+		t.Fatal(err)
+	}
+	if port != 10000 {
+		t.Errorf("expected port from environment, got %d", port)
+	}
+	if !reflect.DeepEqual(namespaces, []string{"foo", "bar"}) {
+		t.Errorf("expected namespaces from environment, got %v", namespaces)
+	}
+}
+
+func TestDynamicConfigValueEnvVar(t *testing.T) {
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	var start *cli.Command
+	for _, cmd := range temporaliteCLI.Commands {
+		if cmd.Name == "start" {
+			start = cmd
+		}
+	}
+	var values map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue
+	start.Action = func(c *cli.Context) error {
+		args, _ := dynamicConfigValueArgs(c)
+		var err error
+		values, err = getDynamicConfigValues(args)
+		return err
+	}
+
+	// JSON values may contain commas, so values are separated by newlines
+	t.Setenv("TEMPORALITE_DYNAMIC_CONFIG_VALUE", `test.list=[1,2]
+history.defaultActivityRetryPolicy={"InitialIntervalInSeconds":1,"MaximumAttempts":3}
+`)
+	if err := temporaliteCLI.Run([]string{"temporalite", "start"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue{
+		"test.list": {{Value: []interface{}{float64(1), float64(2)}}},
+		dynamicconfig.DefaultActivityRetryPolicy: {{Value: map[string]interface{}{
+			"InitialIntervalInSeconds": float64(1),
+			"MaximumAttempts":          float64(3),
+		}}},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected dynamic config values %v", values)
+	}
+
+	// Flags take precedence over the environment variable
+	if err := temporaliteCLI.Run([]string{"temporalite", "start", "--dynamic-config-value", "limit.maxIDLength=100"}); err != nil {
+		t.Fatal(err)
+	}
+	expected = map[dynamicconfig.Key][]dynamicconfig.ConstrainedValue{
+		dynamicconfig.MaxIDLengthLimit: {{Value: float64(100)}},
+	}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("unexpected dynamic config values %v", values)
+	}
+}
+
 func newServerAndClientOpts(port int, customArgs ...string) ([]string, client.Options) {
 	args := []string{
 		"temporalite",
@@ -219,3 +363,62 @@ func TestCreateDataDirectory(t *testing.T) {
 		assertServerHealth(t, ctx, clientOpts)
 	})
 }
+
+func TestStartAutoVacuum(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+
+	temporaliteCLI := buildCLI()
+	// Don't call os.Exit
+	temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+
+	portProvider := liteconfig.NewPortProvider()
+	port := portProvider.MustGetFreePort()
+	portProvider.Close()
+
+	args, clientOpts := newServerAndClientOpts(port, "-f", dbPath, "--auto-vacuum", "100ms")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := temporaliteCLI.RunContext(ctx, args); err != nil {
+			fmt.Println("Server closed with error:", err)
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assertServerHealth(t, ctx, clientOpts)
+
+	// Deleted rows leave free pages behind, which maintenance returns to the file system.
+	// The config matches the server's, so the statements go through its connection.
+	serverConfig := &config.SQL{
+		PluginName:        sqliteplugin.PluginName,
+		DatabaseName:      dbPath,
+		ConnectAttributes: map[string]string{"mode": "rwc"},
+	}
+	if err := litedb.Exec(serverConfig,
+		"CREATE TABLE junk (data BLOB)",
+		"INSERT INTO junk WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n LIMIT 4096) SELECT randomblob(1024) FROM n",
+		"DROP TABLE junk",
+	); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		info, err := os.Stat(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() < 4<<20 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("database file did not shrink, size is %d", info.Size())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
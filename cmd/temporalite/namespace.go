@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/api/enums/v1"
+	"gopkg.in/yaml.v3"
+
+	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
+)
+
+// namespaceFile is the format of the file passed to --namespace-config.
+type namespaceFile struct {
+	Namespaces []struct {
+		Name               string            `yaml:"name"`
+		Description        string            `yaml:"description"`
+		OwnerEmail         string            `yaml:"ownerEmail"`
+		Retention          time.Duration     `yaml:"retention"`
+		SearchAttributes   map[string]string `yaml:"searchAttributes"`
+		HistoryArchival    string            `yaml:"historyArchival"`
+		VisibilityArchival string            `yaml:"visibilityArchival"`
+	} `yaml:"namespaces"`
+}
+
+var searchAttributeTypes = map[string]enums.IndexedValueType{
+	"text":        enums.INDEXED_VALUE_TYPE_TEXT,
+	"keyword":     enums.INDEXED_VALUE_TYPE_KEYWORD,
+	"int":         enums.INDEXED_VALUE_TYPE_INT,
+	"double":      enums.INDEXED_VALUE_TYPE_DOUBLE,
+	"bool":        enums.INDEXED_VALUE_TYPE_BOOL,
+	"datetime":    enums.INDEXED_VALUE_TYPE_DATETIME,
+	"keywordlist": enums.INDEXED_VALUE_TYPE_KEYWORD_LIST,
+}
+
+func parseSearchAttributeType(input string) (enums.IndexedValueType, error) {
+	t, ok := searchAttributeTypes[strings.ToLower(input)]
+	if !ok {
+		return 0, fmt.Errorf("unknown type %q", input)
+	}
+	return t, nil
+}
+
+var archivalStates = map[string]enums.ArchivalState{
+	"":         enums.ARCHIVAL_STATE_UNSPECIFIED,
+	"enabled":  enums.ARCHIVAL_STATE_ENABLED,
+	"disabled": enums.ARCHIVAL_STATE_DISABLED,
+}
+
+func loadNamespaceSpecs(path string) ([]temporalite.NamespaceSpec, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file namespaceFile
+	if err := yaml.Unmarshal(b, &file); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+
+	var specs []temporalite.NamespaceSpec
+	for _, ns := range file.Namespaces {
+		spec := temporalite.NamespaceSpec{
+			Name:        ns.Name,
+			Description: ns.Description,
+			OwnerEmail:  ns.OwnerEmail,
+			Retention:   ns.Retention,
+		}
+		for name, typeName := range ns.SearchAttributes {
+			t, err := parseSearchAttributeType(typeName)
+			if err != nil {
+				return nil, fmt.Errorf("namespace %q: search attribute %q: %w", ns.Name, name, err)
+			}
+			if spec.SearchAttributes == nil {
+				spec.SearchAttributes = make(map[string]enums.IndexedValueType)
+			}
+			spec.SearchAttributes[name] = t
+		}
+		var ok bool
+		if spec.HistoryArchival, ok = archivalStates[strings.ToLower(ns.HistoryArchival)]; !ok {
+			return nil, fmt.Errorf("namespace %q: bad history archival state %q", ns.Name, ns.HistoryArchival)
+		}
+		if spec.VisibilityArchival, ok = archivalStates[strings.ToLower(ns.VisibilityArchival)]; !ok {
+			return nil, fmt.Errorf("namespace %q: bad visibility archival state %q", ns.Name, ns.VisibilityArchival)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+const (
+	addressFlag     = "address"
+	descriptionFlag = "description"
+	ownerEmailFlag  = "owner-email"
+	retentionFlag   = "retention"
+)
+
+func newNamespaceCommand(defaultCfg *liteconfig.Config) *cli.Command {
+	storeFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:  addressFlag,
+			Usage: "host:port of a running server; when unset, the database file is accessed directly",
+		},
+		&cli.StringFlag{
+			Name:    dbPathFlag,
+			Aliases: []string{"f"},
+			Value:   defaultCfg.DatabaseFilePath,
+			Usage:   "file in which Temporal state is persisted; changes to it are picked up the next time the server starts",
+		},
+	}
+	settingsFlags := append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  descriptionFlag,
+			Usage: "namespace description",
+		},
+		&cli.StringFlag{
+			Name:  ownerEmailFlag,
+			Usage: "email address of the namespace owner",
+		},
+		&cli.DurationFlag{
+			Name:        retentionFlag,
+			Usage:       "how long closed workflows are retained",
+			DefaultText: defaultRetention.String() + " for new namespaces",
+		},
+	}, storeFlags...)
+
+	return &cli.Command{
+		Name:  "namespace",
+		Usage: "Manage namespaces of a running server or of a database file",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "List namespaces",
+				ArgsUsage: " ",
+				Flags:     storeFlags,
+				Action: withNamespaceStore(true, func(c *cli.Context, store namespaceStore) error {
+					namespaces, err := store.List(c.Context)
+					if err != nil {
+						return err
+					}
+					w := tabwriter.NewWriter(c.App.Writer, 0, 8, 2, ' ', 0)
+					_, _ = fmt.Fprintln(w, "NAME\tSTATE\tRETENTION\tDESCRIPTION")
+					for _, ns := range namespaces {
+						_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ns.Name, ns.State, ns.Retention, ns.Description)
+					}
+					return w.Flush()
+				}),
+			},
+			{
+				Name:      "describe",
+				Usage:     "Show the settings of a namespace",
+				ArgsUsage: "NAME",
+				Flags:     storeFlags,
+				Action: withNamespaceStore(true, func(c *cli.Context, store namespaceStore) error {
+					ns, err := store.Describe(c.Context, c.Args().First())
+					if err != nil {
+						return err
+					}
+					w := tabwriter.NewWriter(c.App.Writer, 0, 8, 2, ' ', 0)
+					_, _ = fmt.Fprintf(w, "Name:\t%s\n", ns.Name)
+					_, _ = fmt.Fprintf(w, "ID:\t%s\n", ns.ID)
+					_, _ = fmt.Fprintf(w, "State:\t%s\n", ns.State)
+					_, _ = fmt.Fprintf(w, "Description:\t%s\n", ns.Description)
+					_, _ = fmt.Fprintf(w, "Owner email:\t%s\n", ns.OwnerEmail)
+					_, _ = fmt.Fprintf(w, "Retention:\t%s\n", ns.Retention)
+					_, _ = fmt.Fprintf(w, "History archival:\t%s\n", ns.HistoryArchival)
+					_, _ = fmt.Fprintf(w, "Visibility archival:\t%s\n", ns.VisibilityArchival)
+					return w.Flush()
+				}),
+			},
+			{
+				Name:      "create",
+				Usage:     "Create a namespace",
+				ArgsUsage: "NAME",
+				Flags:     settingsFlags,
+				Action: withNamespaceStore(false, func(c *cli.Context, store namespaceStore) error {
+					if err := store.Create(c.Context, namespaceSpecFromFlags(c)); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Created namespace %s\n", c.Args().First())
+					return nil
+				}),
+			},
+			{
+				Name:      "update",
+				Usage:     "Change the settings of a namespace; settings that are not passed are left unchanged",
+				ArgsUsage: "NAME",
+				Flags:     settingsFlags,
+				Action: withNamespaceStore(false, func(c *cli.Context, store namespaceStore) error {
+					if err := store.Update(c.Context, namespaceSpecFromFlags(c)); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Updated namespace %s\n", c.Args().First())
+					return nil
+				}),
+			},
+			{
+				Name:      "delete",
+				Usage:     "Delete a namespace; when accessing the database file directly, its workflows are left in place",
+				ArgsUsage: "NAME",
+				Flags:     storeFlags,
+				Action: withNamespaceStore(false, func(c *cli.Context, store namespaceStore) error {
+					if err := store.Delete(c.Context, c.Args().First()); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Deleted namespace %s\n", c.Args().First())
+					return nil
+				}),
+			},
+		},
+	}
+}
+
+// withNamespaceStore validates the arguments of a namespace subcommand and runs action
+// against the server at --address, or the database file otherwise.
+func withNamespaceStore(readOnly bool, action func(*cli.Context, namespaceStore) error) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		needsName := c.Command.ArgsUsage == "NAME"
+		if needsName && c.Args().Len() != 1 {
+			return cli.Exit("ERROR: a namespace name is required", 1)
+		}
+		if !needsName && c.Args().Len() > 0 {
+			return cli.Exit(fmt.Sprintf("ERROR: %s command doesn't support arguments.", c.Command.Name), 1)
+		}
+		if c.IsSet(addressFlag) && c.IsSet(dbPathFlag) {
+			return cli.Exit(fmt.Sprintf("ERROR: only one of %q or %q flags may be passed at a time", addressFlag, dbPathFlag), 1)
+		}
+
+		var store namespaceStore
+		if c.IsSet(addressFlag) {
+			s, err := newFrontendNamespaceStore(c.String(addressFlag))
+			if err != nil {
+				return err
+			}
+			store = s
+		} else {
+			if _, err := os.Stat(c.String(dbPathFlag)); err != nil {
+				return cli.Exit(fmt.Sprintf("ERROR: %s", err), 1)
+			}
+			store = newFileNamespaceStore(c.String(dbPathFlag), readOnly)
+		}
+		defer func() { _ = store.Close() }()
+
+		return action(c, store)
+	}
+}
+
+func namespaceSpecFromFlags(c *cli.Context) temporalite.NamespaceSpec {
+	return temporalite.NamespaceSpec{
+		Name:        c.Args().First(),
+		Description: c.String(descriptionFlag),
+		OwnerEmail:  c.String(ownerEmailFlag),
+		Retention:   c.Duration(retentionFlag),
+	}
+}
@@ -0,0 +1,234 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	namespacepb "go.temporal.io/api/namespace/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	persistencespb "go.temporal.io/server/api/persistence/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/primitives/timestamp"
+	"go.temporal.io/server/schema/sqlite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// Retention period of namespaces created without an explicit one.
+const defaultRetention = 24 * time.Hour
+
+// namespaceInfo is the subset of namespace settings managed by the namespace command.
+type namespaceInfo struct {
+	Name               string
+	ID                 string
+	State              enums.NamespaceState
+	Description        string
+	OwnerEmail         string
+	Retention          time.Duration
+	HistoryArchival    enums.ArchivalState
+	VisibilityArchival enums.ArchivalState
+}
+
+// namespaceStore manages namespaces either through a running server or directly
+// in a database file.
+type namespaceStore interface {
+	List(ctx context.Context) ([]namespaceInfo, error)
+	Describe(ctx context.Context, name string) (namespaceInfo, error)
+	Create(ctx context.Context, spec temporalite.NamespaceSpec) error
+	// Update changes the settings set in spec, leaving the others unchanged.
+	Update(ctx context.Context, spec temporalite.NamespaceSpec) error
+	Delete(ctx context.Context, name string) error
+	Close() error
+}
+
+// frontendNamespaceStore manages namespaces through the frontend of a running server.
+type frontendNamespaceStore struct {
+	conn *grpc.ClientConn
+}
+
+func newFrontendNamespaceStore(address string) (*frontendNamespaceStore, error) {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &frontendNamespaceStore{conn: conn}, nil
+}
+
+func (s *frontendNamespaceStore) List(ctx context.Context) ([]namespaceInfo, error) {
+	var (
+		namespaces []namespaceInfo
+		token      []byte
+	)
+	for {
+		resp, err := workflowservice.NewWorkflowServiceClient(s.conn).ListNamespaces(ctx, &workflowservice.ListNamespacesRequest{
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range resp.GetNamespaces() {
+			namespaces = append(namespaces, namespaceInfoFromDescription(ns))
+		}
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			return namespaces, nil
+		}
+	}
+}
+
+func (s *frontendNamespaceStore) Describe(ctx context.Context, name string) (namespaceInfo, error) {
+	resp, err := workflowservice.NewWorkflowServiceClient(s.conn).DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{
+		Namespace: name,
+	})
+	if err != nil {
+		return namespaceInfo{}, err
+	}
+	return namespaceInfoFromDescription(resp), nil
+}
+
+func (s *frontendNamespaceStore) Create(ctx context.Context, spec temporalite.NamespaceSpec) error {
+	retention := spec.Retention
+	if retention == 0 {
+		retention = defaultRetention
+	}
+	_, err := workflowservice.NewWorkflowServiceClient(s.conn).RegisterNamespace(ctx, &workflowservice.RegisterNamespaceRequest{
+		Namespace:                        spec.Name,
+		Description:                      spec.Description,
+		OwnerEmail:                       spec.OwnerEmail,
+		WorkflowExecutionRetentionPeriod: timestamp.DurationPtr(retention),
+		HistoryArchivalState:             spec.HistoryArchival,
+		VisibilityArchivalState:          spec.VisibilityArchival,
+	})
+	return err
+}
+
+func (s *frontendNamespaceStore) Update(ctx context.Context, spec temporalite.NamespaceSpec) error {
+	req := &workflowservice.UpdateNamespaceRequest{
+		Namespace: spec.Name,
+		UpdateInfo: &namespacepb.UpdateNamespaceInfo{
+			Description: spec.Description,
+			OwnerEmail:  spec.OwnerEmail,
+		},
+		Config: &namespacepb.NamespaceConfig{
+			HistoryArchivalState:    spec.HistoryArchival,
+			VisibilityArchivalState: spec.VisibilityArchival,
+		},
+	}
+	if spec.Retention != 0 {
+		req.Config.WorkflowExecutionRetentionTtl = timestamp.DurationPtr(spec.Retention)
+	}
+	_, err := workflowservice.NewWorkflowServiceClient(s.conn).UpdateNamespace(ctx, req)
+	return err
+}
+
+func (s *frontendNamespaceStore) Delete(ctx context.Context, name string) error {
+	_, err := operatorservice.NewOperatorServiceClient(s.conn).DeleteNamespace(ctx, &operatorservice.DeleteNamespaceRequest{
+		Namespace: name,
+	})
+	return err
+}
+
+func (s *frontendNamespaceStore) Close() error {
+	return s.conn.Close()
+}
+
+func namespaceInfoFromDescription(ns *workflowservice.DescribeNamespaceResponse) namespaceInfo {
+	return namespaceInfo{
+		Name:               ns.GetNamespaceInfo().GetName(),
+		ID:                 ns.GetNamespaceInfo().GetId(),
+		State:              ns.GetNamespaceInfo().GetState(),
+		Description:        ns.GetNamespaceInfo().GetDescription(),
+		OwnerEmail:         ns.GetNamespaceInfo().GetOwnerEmail(),
+		Retention:          timestamp.DurationValue(ns.GetConfig().GetWorkflowExecutionRetentionTtl()),
+		HistoryArchival:    ns.GetConfig().GetHistoryArchivalState(),
+		VisibilityArchival: ns.GetConfig().GetVisibilityArchivalState(),
+	}
+}
+
+// fileNamespaceStore manages namespaces directly in a database file. Changes are
+// only picked up by a server started afterwards.
+type fileNamespaceStore struct {
+	cfg *config.SQL
+}
+
+func newFileNamespaceStore(path string, readOnly bool) *fileNamespaceStore {
+	return &fileNamespaceStore{cfg: litedb.NewFileConfig(path, readOnly)}
+}
+
+func (s *fileNamespaceStore) List(context.Context) ([]namespaceInfo, error) {
+	details, err := litedb.ListNamespaces(s.cfg, liteconfig.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	var namespaces []namespaceInfo
+	for _, detail := range details {
+		namespaces = append(namespaces, namespaceInfoFromDetail(detail))
+	}
+	return namespaces, nil
+}
+
+func (s *fileNamespaceStore) Describe(_ context.Context, name string) (namespaceInfo, error) {
+	detail, ok, err := litedb.GetNamespace(s.cfg, liteconfig.ClusterName, name)
+	if err != nil {
+		return namespaceInfo{}, err
+	}
+	if !ok {
+		return namespaceInfo{}, fmt.Errorf("namespace %q not found", name)
+	}
+	return namespaceInfoFromDetail(detail), nil
+}
+
+func (s *fileNamespaceStore) Create(_ context.Context, spec temporalite.NamespaceSpec) error {
+	if _, ok, err := litedb.GetNamespace(s.cfg, liteconfig.ClusterName, spec.Name); err != nil {
+		return err
+	} else if ok {
+		return fmt.Errorf("namespace %q already exists", spec.Name)
+	}
+	detail := sqlite.NewNamespaceConfig(liteconfig.ClusterName, spec.Name, false).Detail
+	litedb.ApplyNamespaceSpec(detail, spec, config.ArchivalNamespaceDefaults{})
+	return litedb.CreateNamespace(s.cfg, liteconfig.ClusterName, detail)
+}
+
+func (s *fileNamespaceStore) Update(_ context.Context, spec temporalite.NamespaceSpec) error {
+	detail, ok, err := litedb.GetNamespace(s.cfg, liteconfig.ClusterName, spec.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("namespace %q not found", spec.Name)
+	}
+	litedb.ApplyNamespaceSpec(detail, spec, config.ArchivalNamespaceDefaults{})
+	detail.ConfigVersion++
+	return litedb.UpdateNamespace(s.cfg, liteconfig.ClusterName, detail)
+}
+
+func (s *fileNamespaceStore) Delete(_ context.Context, name string) error {
+	return litedb.DeleteNamespace(s.cfg, liteconfig.ClusterName, name)
+}
+
+func (s *fileNamespaceStore) Close() error {
+	return nil
+}
+
+func namespaceInfoFromDetail(detail *persistencespb.NamespaceDetail) namespaceInfo {
+	return namespaceInfo{
+		Name:               detail.GetInfo().GetName(),
+		ID:                 detail.GetInfo().GetId(),
+		State:              detail.GetInfo().GetState(),
+		Description:        detail.GetInfo().GetDescription(),
+		OwnerEmail:         detail.GetInfo().GetOwner(),
+		Retention:          timestamp.DurationValue(detail.GetConfig().GetRetention()),
+		HistoryArchival:    detail.GetConfig().GetHistoryArchivalState(),
+		VisibilityArchival: detail.GetConfig().GetVisibilityArchivalState(),
+	}
+}
@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/server/common/log"
+
+	"github.com/temporalio/temporalite"
+)
+
+func TestLoadNamespaceSpecs(t *testing.T) {
+	writeFile := func(content string) string {
+		path := filepath.Join(t.TempDir(), "namespaces.yaml")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	specs, err := loadNamespaceSpecs(writeFile(`
+namespaces:
+  - name: orders
+    description: Order processing
+    ownerEmail: orders@example.com
+    retention: 72h
+    historyArchival: enabled
+    searchAttributes:
+      CustomerId: Keyword
+      Total: Double
+  - name: default
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []temporalite.NamespaceSpec{
+		{
+			Name:        "orders",
+			Description: "Order processing",
+			OwnerEmail:  "orders@example.com",
+			Retention:   72 * time.Hour,
+			SearchAttributes: map[string]enums.IndexedValueType{
+				"CustomerId": enums.INDEXED_VALUE_TYPE_KEYWORD,
+				"Total":      enums.INDEXED_VALUE_TYPE_DOUBLE,
+			},
+			HistoryArchival: enums.ARCHIVAL_STATE_ENABLED,
+		},
+		{Name: "default"},
+	}
+	if !reflect.DeepEqual(expected, specs) {
+		t.Fatalf("not equal, expected - actual: %v - %v", expected, specs)
+	}
+
+	for _, content := range []string{
+		"namespaces: foo",
+		"namespaces: [{name: foo, retention: forever}]",
+		"namespaces: [{name: foo, searchAttributes: {CustomerId: Uuid}}]",
+		"namespaces: [{name: foo, visibilityArchival: paused}]",
+	} {
+		if _, err := loadNamespaceSpecs(writeFile(content)); err == nil {
+			t.Errorf("expected error for %q", content)
+		}
+	}
+}
+
+func TestNamespaceCommand(t *testing.T) {
+	run := func(t *testing.T, target []string, command string, args ...string) (string, error) {
+		var out bytes.Buffer
+		temporaliteCLI := buildCLI()
+		temporaliteCLI.Writer = &out
+		temporaliteCLI.ErrWriter = io.Discard
+		// Don't call os.Exit
+		temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+		err := temporaliteCLI.Run(append(append([]string{"temporalite", "namespace", command}, target...), args...))
+		return out.String(), err
+	}
+
+	// The server only notices namespace changes once it refreshes its namespace cache
+	eventually := func(t *testing.T, condition func() bool) {
+		deadline := time.Now().Add(30 * time.Second)
+		for !condition() {
+			if time.Now().After(deadline) {
+				t.Fatal("condition not met in time")
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	testNamespaceCommand := func(t *testing.T, target []string) {
+		if _, err := run(t, target, "create", "--description", "Order processing", "--retention", "72h", "orders"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := run(t, target, "create", "orders"); err == nil {
+			t.Error("expected error when namespace already exists")
+		}
+		eventually(t, func() bool {
+			_, err := run(t, target, "update", "--owner-email", "orders@example.com", "orders")
+			return err == nil
+		})
+
+		out, err := run(t, target, "describe", "orders")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{"Order processing", "orders@example.com", "72h0m0s"} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("expected %q in description:\n%s", expected, out)
+			}
+		}
+
+		out, err = run(t, target, "list")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, "orders") {
+			t.Errorf("expected orders namespace to be listed:\n%s", out)
+		}
+
+		if _, err := run(t, target, "delete", "orders"); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, func() bool {
+			_, err := run(t, target, "describe", "orders")
+			return err != nil
+		})
+	}
+
+	t.Run("file", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+		// Creating a server sets up the database schema
+		if _, err := temporalite.NewServer(temporalite.WithDatabaseFilePath(dbPath), temporalite.WithDynamicPorts(), temporalite.WithLogger(log.NewNoopLogger())); err != nil {
+			t.Fatal(err)
+		}
+		testNamespaceCommand(t, []string{"-f", dbPath})
+	})
+
+	t.Run("server", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		s, err := temporalite.NewServer(temporalite.WithPersistenceDisabled(), temporalite.WithDynamicPorts(), temporalite.WithLogger(log.NewNoopLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+		if err := s.WaitReady(ctx); err != nil {
+			t.Fatal(err)
+		}
+		testNamespaceCommand(t, []string{"--address", s.FrontendHostPort()})
+	})
+}
@@ -9,6 +9,8 @@ package main
 // This file should be the only one to import ui-server packages.
 // This is to avoid embedding the UI's static assets in the binary when the `headless` build tag is enabled.
 import (
+	"net"
+	"strconv"
 	"strings"
 
 	provider "github.com/temporalio/ui-server/v2/plugins/fs_config_provider"
@@ -17,6 +19,7 @@ import (
 	uiserveroptions "github.com/temporalio/ui-server/v2/server/server_options"
 
 	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
 )
 
 func newUIOption(c *uiConfig, configDir string) (temporalite.ServerOption, error) {
@@ -27,7 +30,48 @@ func newUIOption(c *uiConfig, configDir string) (temporalite.ServerOption, error
 	if err != nil {
 		return nil, err
 	}
-	return temporalite.WithUI(uiserver.NewServer(uiserveroptions.WithConfigProvider(cfg))), nil
+	return temporalite.WithUI(newUIServer(cfg)), nil
+}
+
+// uiServer wraps the ui-server to surface startup failures and to support the
+// readiness and failure policy extensions of liteconfig.UIServer.
+type uiServer struct {
+	*uiserver.Server
+	cfg *uiconfig.Config
+}
+
+func newUIServer(cfg *uiconfig.Config) *uiServer {
+	return &uiServer{
+		Server: uiserver.NewServer(uiserveroptions.WithConfigProvider(cfg)),
+		cfg:    cfg,
+	}
+}
+
+func (s *uiServer) Addr() string {
+	return net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+}
+
+// Start checks that the UI address can be listened on before starting the
+// ui-server, which exits the process instead of returning an error otherwise.
+func (s *uiServer) Start() error {
+	l, err := net.Listen("tcp", s.Addr())
+	if err != nil {
+		return err
+	}
+	if err := l.Close(); err != nil {
+		return err
+	}
+	return s.Server.Start()
+}
+
+func (s *uiServer) Config() interface{} {
+	return s.cfg
+}
+
+func (s *uiServer) WithPort(port int) (liteconfig.UIServer, error) {
+	cfg := *s.cfg
+	cfg.Port = port
+	return newUIServer(&cfg), nil
 }
 
 func newUIConfig(c *uiConfig, configDir string) (*uiconfig.Config, error) {
@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/server/common/codec"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+const (
+	workflowIDFlag = "workflow-id"
+	runIDFlag      = "run-id"
+	taskQueueFlag  = "task-queue"
+)
+
+// Identity reported to the server by the workflow command.
+const workflowCommandIdentity = "temporalite-cli"
+
+func newWorkflowCommand(defaultCfg *liteconfig.Config) *cli.Command {
+	namespace := &cli.StringFlag{
+		Name:    namespaceFlag,
+		Aliases: []string{"n"},
+		Value:   "default",
+		Usage:   "namespace of the workflow",
+	}
+	address := &cli.StringFlag{
+		Name:  addressFlag,
+		Usage: "host:port of a running server",
+	}
+
+	return &cli.Command{
+		Name:  "workflow",
+		Usage: "Export and import workflow histories",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Write the event history of a workflow as JSON, in the format read by the SDK workflow replayer",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					namespace,
+					&cli.StringFlag{
+						Name:     workflowIDFlag,
+						Aliases:  []string{"w"},
+						Usage:    "ID of the workflow",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:        runIDFlag,
+						Aliases:     []string{"r"},
+						Usage:       "ID of the workflow run",
+						DefaultText: "current run",
+					},
+					&cli.StringFlag{
+						Name:    outputFlag,
+						Aliases: []string{"o"},
+						Value:   "-",
+						Usage:   `file to write the history to, or "-" for stdout`,
+					},
+					&cli.StringFlag{
+						Name:  addressFlag,
+						Usage: "host:port of a running server; when unset, the database file is read directly",
+					},
+					&cli.StringFlag{
+						Name:    dbPathFlag,
+						Aliases: []string{"f"},
+						Value:   defaultCfg.DatabaseFilePath,
+						Usage:   "file in which Temporal state is persisted",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.IsSet(addressFlag) && c.IsSet(dbPathFlag) {
+						return cli.Exit(fmt.Sprintf("ERROR: only one of %q or %q flags may be passed at a time", addressFlag, dbPathFlag), 1)
+					}
+
+					var (
+						history *historypb.History
+						err     error
+					)
+					if c.IsSet(addressFlag) {
+						history, err = getWorkflowHistory(c.Context, c.String(addressFlag), c.String(namespaceFlag), c.String(workflowIDFlag), c.String(runIDFlag))
+					} else {
+						if _, err := os.Stat(c.String(dbPathFlag)); err != nil {
+							return cli.Exit(fmt.Sprintf("ERROR: %s", err), 1)
+						}
+						cfg := litedb.NewFileConfig(c.String(dbPathFlag), true)
+						history, err = litedb.GetWorkflowHistory(cfg, liteconfig.ClusterName, c.String(namespaceFlag), c.String(workflowIDFlag), c.String(runIDFlag))
+					}
+					if err != nil {
+						return err
+					}
+
+					b, err := codec.NewJSONPBIndentEncoder("  ").Encode(history)
+					if err != nil {
+						return err
+					}
+					b = append(b, '\n')
+					if c.String(outputFlag) == "-" {
+						_, err = c.App.Writer.Write(b)
+						return err
+					}
+					if err := os.WriteFile(c.String(outputFlag), b, 0644); err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Exported %d events to %s\n", len(history.GetEvents()), c.String(outputFlag))
+					return nil
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Start a workflow from an exported history and send it the signals recorded in the history",
+				ArgsUsage: " ",
+				Flags: []cli.Flag{
+					namespace,
+					&cli.StringFlag{
+						Name:     workflowIDFlag,
+						Aliases:  []string{"w"},
+						Usage:    "ID of the new workflow",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:        taskQueueFlag,
+						Aliases:     []string{"t"},
+						Usage:       "task queue of the new workflow",
+						DefaultText: "task queue recorded in the history",
+					},
+					&cli.StringFlag{
+						Name:     inputFlag,
+						Aliases:  []string{"i"},
+						Usage:    `exported history file, or "-" for stdin`,
+						Required: true,
+					},
+					address,
+				},
+				Action: func(c *cli.Context) error {
+					if !c.IsSet(addressFlag) {
+						return cli.Exit(fmt.Sprintf("ERROR: the %q flag is required, histories can only be imported into a running server", addressFlag), 1)
+					}
+
+					var (
+						b   []byte
+						err error
+					)
+					if c.String(inputFlag) == "-" {
+						b, err = io.ReadAll(os.Stdin)
+					} else {
+						b, err = os.ReadFile(c.String(inputFlag))
+					}
+					if err != nil {
+						return err
+					}
+					var history historypb.History
+					if err := codec.NewJSONPBEncoder().Decode(b, &history); err != nil {
+						return fmt.Errorf("unable to parse %s: %w", c.String(inputFlag), err)
+					}
+
+					runID, signals, err := importWorkflowHistory(c.Context, c.String(addressFlag), c.String(namespaceFlag), c.String(workflowIDFlag), c.String(taskQueueFlag), &history)
+					if err != nil {
+						return err
+					}
+					_, _ = fmt.Fprintf(c.App.ErrWriter, "Started workflow %s (run %s) and sent %d signals\n", c.String(workflowIDFlag), runID, signals)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// getWorkflowHistory returns the event history of a workflow from the frontend at address.
+func getWorkflowHistory(ctx context.Context, address, namespace, workflowID, runID string) (*historypb.History, error) {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var (
+		history historypb.History
+		token   []byte
+	)
+	for {
+		resp, err := workflowservice.NewWorkflowServiceClient(conn).GetWorkflowExecutionHistory(ctx, &workflowservice.GetWorkflowExecutionHistoryRequest{
+			Namespace: namespace,
+			Execution: &commonpb.WorkflowExecution{
+				WorkflowId: workflowID,
+				RunId:      runID,
+			},
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		history.Events = append(history.Events, resp.GetHistory().GetEvents()...)
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			return &history, nil
+		}
+	}
+}
+
+// importWorkflowHistory starts a workflow with the settings recorded in the first
+// event of history, then sends it the signals of the history in order. Workers
+// re-execute the workflow, as the server has no way to load an existing history.
+func importWorkflowHistory(ctx context.Context, address, namespace, workflowID, taskQueue string, history *historypb.History) (string, int, error) {
+	events := history.GetEvents()
+	if len(events) == 0 || events[0].GetEventType() != enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED {
+		return "", 0, fmt.Errorf("history doesn't start with a %s event", enums.EVENT_TYPE_WORKFLOW_EXECUTION_STARTED)
+	}
+	started := events[0].GetWorkflowExecutionStartedEventAttributes()
+	if taskQueue != "" {
+		started.TaskQueue.Name = taskQueue
+		started.TaskQueue.Kind = enums.TASK_QUEUE_KIND_NORMAL
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() { _ = conn.Close() }()
+	client := workflowservice.NewWorkflowServiceClient(conn)
+
+	resp, err := client.StartWorkflowExecution(ctx, &workflowservice.StartWorkflowExecutionRequest{
+		Namespace:                namespace,
+		WorkflowId:               workflowID,
+		WorkflowType:             started.GetWorkflowType(),
+		TaskQueue:                started.GetTaskQueue(),
+		Input:                    started.GetInput(),
+		WorkflowExecutionTimeout: started.GetWorkflowExecutionTimeout(),
+		WorkflowRunTimeout:       started.GetWorkflowRunTimeout(),
+		WorkflowTaskTimeout:      started.GetWorkflowTaskTimeout(),
+		Identity:                 workflowCommandIdentity,
+		RequestId:                uuid.NewString(),
+		RetryPolicy:              started.GetRetryPolicy(),
+		CronSchedule:             started.GetCronSchedule(),
+		Memo:                     started.GetMemo(),
+		SearchAttributes:         started.GetSearchAttributes(),
+		Header:                   started.GetHeader(),
+	})
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to start workflow: %w", err)
+	}
+
+	var signals int
+	for _, event := range events[1:] {
+		if event.GetEventType() != enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED {
+			continue
+		}
+		attributes := event.GetWorkflowExecutionSignaledEventAttributes()
+		if _, err := client.SignalWorkflowExecution(ctx, &workflowservice.SignalWorkflowExecutionRequest{
+			Namespace: namespace,
+			WorkflowExecution: &commonpb.WorkflowExecution{
+				WorkflowId: workflowID,
+				RunId:      resp.GetRunId(),
+			},
+			SignalName: attributes.GetSignalName(),
+			Input:      attributes.GetInput(),
+			Identity:   workflowCommandIdentity,
+			RequestId:  uuid.NewString(),
+			Header:     attributes.GetHeader(),
+		}); err != nil {
+			return resp.GetRunId(), signals, fmt.Errorf("unable to send signal %q: %w", attributes.GetSignalName(), err)
+		}
+		signals++
+	}
+	return resp.GetRunId(), signals, nil
+}
@@ -0,0 +1,143 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/log"
+
+	"github.com/temporalio/temporalite"
+)
+
+func TestWorkflowExportImport(t *testing.T) {
+	dir := t.TempDir()
+	var (
+		dbPath     = filepath.Join(dir, "temporalite.db")
+		onlinePath = filepath.Join(dir, "online.json")
+		filePath   = filepath.Join(dir, "file.json")
+	)
+
+	run := func(t *testing.T, args ...string) (string, error) {
+		var out bytes.Buffer
+		temporaliteCLI := buildCLI()
+		temporaliteCLI.Writer = &out
+		temporaliteCLI.ErrWriter = io.Discard
+		// Don't call os.Exit
+		temporaliteCLI.ExitErrHandler = func(_ *cli.Context, _ error) {}
+		err := temporaliteCLI.Run(append([]string{"temporalite", "workflow"}, args...))
+		return out.String(), err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s, err := temporalite.NewServer(
+		temporalite.WithDatabaseFilePath(dbPath),
+		temporalite.WithNamespaces("default"),
+		temporalite.WithDynamicPorts(),
+		temporalite.WithLogger(log.NewNoopLogger()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	c, err := s.NewClient(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// No worker is running, so the history only holds what the server recorded
+	if _, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{ID: "original", TaskQueue: "export-test"}, "Example", "input"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SignalWorkflow(ctx, "original", "", "first", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SignalWorkflow(ctx, "original", "", "second", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := run(t, "export", "--address", s.FrontendHostPort(), "-w", "original", "-o", onlinePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(t, "export", "--address", s.FrontendHostPort(), "-w", "missing"); err == nil {
+		t.Error("expected error when workflow does not exist")
+	}
+	f, err := os.Open(onlinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	online, err := client.HistoryFromJSON(f, client.HistoryJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if countEvents(online.GetEvents(), enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED) != 2 {
+		t.Errorf("expected 2 signals in exported history, got %v", online.GetEvents())
+	}
+
+	if _, err := run(t, "import", "--address", s.FrontendHostPort(), "-w", "imported", "-i", onlinePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(t, "import", "-w", "imported", "-i", onlinePath); err == nil {
+		t.Error("expected error when importing without a server address")
+	}
+	iter := c.GetWorkflowHistory(ctx, "imported", "", false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	var signals int
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.GetEventType() == enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED {
+			signals++
+		}
+	}
+	if signals != 2 {
+		t.Errorf("expected 2 signals in imported workflow, got %d", signals)
+	}
+
+	// Histories can also be read from the database file directly
+	out, err := run(t, "export", "-f", dbPath, "-w", "original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run(t, "export", "-f", dbPath, "--address", s.FrontendHostPort(), "-w", "original", "-o", filePath); err == nil {
+		t.Error("expected error when both address and filename are passed")
+	}
+	fromFile, err := client.HistoryFromJSON(bytes.NewBufferString(out), client.HistoryJSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fromFile.GetEvents()) != len(online.GetEvents()) {
+		t.Errorf("expected %d events when reading the database file, got %d", len(online.GetEvents()), len(fromFile.GetEvents()))
+	}
+}
+
+func countEvents(events []*historypb.HistoryEvent, eventType enums.EventType) int {
+	var count int
+	for _, event := range events {
+		if event.GetEventType() == eventType {
+			count++
+		}
+	}
+	return count
+}
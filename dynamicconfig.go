@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.temporal.io/server/common/dynamicconfig"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/log/tag"
+	"gopkg.in/yaml.v3"
+)
+
+// Interval at which the dynamic config file is checked for changes by default.
+const defaultDynamicConfigPollInterval = 10 * time.Second
+
+// layeredDynamicConfig serves the dynamic config values set with
+// WithDynamicConfigValue, falling back to the values of the dynamic config file,
+// which is reloaded when it changes.
+type layeredDynamicConfig struct {
+	overrides dynamicconfig.StaticClient
+	file      dynamicconfig.Client
+	reader    *dynamicConfigFileReader
+}
+
+func newLayeredDynamicConfig(fileConfig *dynamicconfig.FileBasedClientConfig, overrides dynamicconfig.StaticClient, logger log.Logger, doneCh <-chan interface{}) (*layeredDynamicConfig, error) {
+	reader := &dynamicConfigFileReader{overrides: overrides, logger: logger}
+	// The file-based client polls the file and logs the values that change
+	file, err := dynamicconfig.NewFileBasedClientWithReader(reader, fileConfig, logger, doneCh)
+	if err != nil {
+		return nil, err
+	}
+	return &layeredDynamicConfig{
+		overrides: overrides,
+		file:      file,
+		reader:    reader,
+	}, nil
+}
+
+func (c *layeredDynamicConfig) GetValue(key dynamicconfig.Key) []dynamicconfig.ConstrainedValue {
+	if values := c.overrides.GetValue(key); len(values) > 0 {
+		return values
+	}
+	return c.file.GetValue(key)
+}
+
+// values returns the merged values of the file and the overrides, in the format of
+// dynamic config files.
+func (c *layeredDynamicConfig) values() map[string][]dynamicConfigValue {
+	values := c.reader.values()
+	for key, v := range dynamicConfigFile(c.overrides) {
+		// Keys of the file are case-insensitive
+		for k := range values {
+			if strings.EqualFold(k, key) {
+				delete(values, k)
+			}
+		}
+		values[key] = v
+	}
+	return values
+}
+
+// dynamicConfigFileReader reads the dynamic config file for the file-based client,
+// and keeps the last valid content to report the merged values.
+type dynamicConfigFileReader struct {
+	overrides dynamicconfig.StaticClient
+	logger    log.Logger
+
+	mu      sync.Mutex
+	content map[string][]dynamicConfigValue
+}
+
+func (r *dynamicConfigFileReader) Stat(src string) (os.FileInfo, error) {
+	return os.Stat(src)
+}
+
+// ReadFile is only called when the file has changed.
+func (r *dynamicConfigFileReader) ReadFile(src string) ([]byte, error) {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string][]dynamicConfigValue
+	if err := yaml.Unmarshal(b, &content); err != nil {
+		// The file-based client reports the error and keeps the previous values
+		return b, nil
+	}
+	for key := range content {
+		for override := range r.overrides {
+			if strings.EqualFold(key, string(override)) {
+				r.logger.Info("Dynamic config value from file is overridden", tag.NewStringTag("key", key))
+			}
+		}
+	}
+
+	r.mu.Lock()
+	r.content = content
+	r.mu.Unlock()
+	return b, nil
+}
+
+func (r *dynamicConfigFileReader) values() map[string][]dynamicConfigValue {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[string][]dynamicConfigValue, len(r.content))
+	for k, v := range r.content {
+		values[k] = v
+	}
+	return values
+}
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"fmt"
+	"os"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/dynamicconfig"
+	"gopkg.in/yaml.v3"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// Replaces the value of secrets in EffectiveConfig.
+const redacted = "******"
+
+// Keys of the configuration values that are redacted from EffectiveConfig.
+var secretKeys = map[string]bool{
+	"password":     true,
+	"keyData":      true,
+	"clientSecret": true,
+}
+
+// EffectiveConfig is the configuration a Server runs with, after options, the base
+// config and Temporalite's own settings have been merged.
+//
+// Server, DynamicConfig and UI hold the YAML representation of the corresponding
+// configuration, with passwords, private keys and client secrets redacted. The
+// whole struct can be marshaled to YAML or JSON.
+type EffectiveConfig struct {
+	// FrontendHostPort is the address clients connect to.
+	FrontendHostPort string `json:"frontendHostPort" yaml:"frontendHostPort"`
+	// MetricsAddress is the address metrics are served on.
+	MetricsAddress string `json:"metricsAddress,omitempty" yaml:"metricsAddress,omitempty"`
+	// UIAddress is the address the web UI listens on, when it is enabled and the UI
+	// server implements liteconfig.AddressableUIServer.
+	UIAddress string `json:"uiAddress,omitempty" yaml:"uiAddress,omitempty"`
+	// Server is the Temporal server config.
+	Server map[string]interface{} `json:"server" yaml:"server"`
+	// DynamicConfig holds the current values of the dynamic config file merged
+	// with the values set with WithDynamicConfigValue, in the format of dynamic
+	// config files.
+	DynamicConfig map[string]interface{} `json:"dynamicConfig,omitempty" yaml:"dynamicConfig,omitempty"`
+	// UI is the web UI config, when the UI server implements
+	// liteconfig.DescribableUIServer.
+	UI map[string]interface{} `json:"ui,omitempty" yaml:"ui,omitempty"`
+}
+
+// EffectiveConfig returns the configuration the server runs with, including the
+// ports chosen by WithDynamicPorts.
+//
+// The UI address reflects the port chosen by UIRetryOnAnotherPort once Start has
+// returned.
+func (s *Server) EffectiveConfig() (*EffectiveConfig, error) {
+	values := dynamicConfigFile(s.config.DynamicConfig)
+	if s.dynamicConfig != nil {
+		values = s.dynamicConfig.values()
+	}
+	return newEffectiveConfig(s.serverConfig, values, s.ui)
+}
+
+// DescribeConfig returns the configuration a server created with opts would run
+// with, without creating it.
+//
+// Unlike NewServer, it does not create, migrate or otherwise modify database
+// files. The number of history shards recorded in an existing database file is
+// read, and the dynamic config file is loaded once. Ports chosen by the system
+// differ from those a server would choose.
+func DescribeConfig(opts ...ServerOption) (*EffectiveConfig, error) {
+	c, cfg, _, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.Ephemeral {
+		if _, err := os.Stat(c.DatabaseFilePath); err == nil {
+			shards, ok, err := litedb.HistoryShardCount(litedb.NewFileConfig(c.DatabaseFilePath, true), cfg.ClusterMetadata.CurrentClusterName)
+			if err != nil {
+				return nil, fmt.Errorf("error reading number of history shards: %w", err)
+			}
+			if ok {
+				cfg.Persistence.NumHistoryShards = shards
+			}
+		}
+	}
+
+	values := dynamicConfigFile(c.DynamicConfig)
+	if cfg.DynamicConfigClient != nil {
+		done := make(chan interface{})
+		defer close(done)
+		dynamicConfig, err := newLayeredDynamicConfig(cfg.DynamicConfigClient, c.DynamicConfig, c.Logger, done)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load dynamic config file: %w", err)
+		}
+		values = dynamicConfig.values()
+	}
+	return newEffectiveConfig(cfg, values, c.UIServer)
+}
+
+func newEffectiveConfig(cfg *config.Config, values map[string][]dynamicConfigValue, ui liteconfig.UIServer) (*EffectiveConfig, error) {
+	ec := &EffectiveConfig{
+		FrontendHostPort: cfg.PublicClient.HostPort,
+	}
+	if m := cfg.Global.Metrics; m != nil && m.Prometheus != nil {
+		ec.MetricsAddress = m.Prometheus.ListenAddress
+	}
+	if ui, ok := ui.(liteconfig.AddressableUIServer); ok {
+		ec.UIAddress = ui.Addr()
+	}
+
+	var err error
+	if ec.Server, err = redactedMap(cfg); err != nil {
+		return nil, fmt.Errorf("unable to describe server config: %w", err)
+	}
+	if len(values) > 0 {
+		if ec.DynamicConfig, err = redactedMap(values); err != nil {
+			return nil, fmt.Errorf("unable to describe dynamic config: %w", err)
+		}
+	}
+	if ui, ok := ui.(liteconfig.DescribableUIServer); ok {
+		if ec.UI, err = redactedMap(ui.Config()); err != nil {
+			return nil, fmt.Errorf("unable to describe UI config: %w", err)
+		}
+	}
+	return ec, nil
+}
+
+// dynamicConfigValue is the format of a value in dynamic config files.
+type dynamicConfigValue struct {
+	Value       interface{}            `yaml:"value"`
+	Constraints map[string]interface{} `yaml:"constraints,omitempty"`
+}
+
+func dynamicConfigFile(client dynamicconfig.StaticClient) map[string][]dynamicConfigValue {
+	file := make(map[string][]dynamicConfigValue, len(client))
+	for key := range client {
+		for _, v := range client.GetValue(key) {
+			file[string(key)] = append(file[string(key)], dynamicConfigValue{
+				Value:       v.Value,
+				Constraints: dynamicConfigConstraints(v.Constraints),
+			})
+		}
+	}
+	return file
+}
+
+// dynamicConfigConstraints returns the constraints that are set, as they are
+// named in dynamic config files.
+func dynamicConfigConstraints(c dynamicconfig.Constraints) map[string]interface{} {
+	m := make(map[string]interface{})
+	if c.Namespace != "" {
+		m["namespace"] = c.Namespace
+	}
+	if c.NamespaceID != "" {
+		m["namespaceId"] = c.NamespaceID
+	}
+	if c.TaskQueueName != "" {
+		m["taskQueueName"] = c.TaskQueueName
+	}
+	if c.TaskQueueType != enums.TASK_QUEUE_TYPE_UNSPECIFIED {
+		m["taskType"] = c.TaskQueueType.String()
+	}
+	if c.ShardID != 0 {
+		m["shardId"] = c.ShardID
+	}
+	if c.TaskType != 0 {
+		m["historyTaskType"] = c.TaskType.String()
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// redactedMap converts v to a map through its YAML representation, and redacts
+// the secrets it contains.
+func redactedMap(v interface{}) (map[string]interface{}, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	redact(m)
+	return m, nil
+}
+
+func redact(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if s, ok := e.(string); ok && secretKeys[k] && s != "" {
+				v[k] = redacted
+				continue
+			}
+			redact(e)
+		}
+	case []interface{}:
+		for _, e := range v {
+			redact(e)
+		}
+	}
+}
@@ -9,9 +9,10 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"time"
 
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/server/common/cluster"
 	"go.temporal.io/server/common/config"
 	"go.temporal.io/server/common/dynamicconfig"
@@ -24,6 +25,8 @@ import (
 const (
 	broadcastAddress     = "127.0.0.1"
 	PersistenceStoreName = "sqlite-default"
+	VisibilityStoreName  = "sqlite-visibility"
+	ClusterName          = "active"
 	DefaultFrontendPort  = 7233
 	DefaultMetricsPort   = 0
 )
@@ -38,43 +41,94 @@ type UIServer interface {
 	Stop()
 }
 
-type noopUIServer struct{}
+// NoopUIServer is the UIServer used when running in headless mode.
+type NoopUIServer struct{}
 
-func (noopUIServer) Start() error {
+func (NoopUIServer) Start() error {
 	return nil
 }
 
-func (noopUIServer) Stop() {}
+func (NoopUIServer) Stop() {}
 
-type Config struct {
-	Ephemeral        bool
-	DatabaseFilePath string
-	FrontendPort     int
-	MetricsPort      int
-	DynamicPorts     bool
-	Namespaces       []string
-	SQLitePragmas    map[string]string
-	Logger           log.Logger
-	UpstreamOptions  []temporal.ServerOption
-	portProvider     *PortProvider
-	FrontendIP       string
-	UIServer         UIServer
-	BaseConfig       *config.Config
-	DynamicConfig    dynamicconfig.StaticClient
+// AddressableUIServer is an optional extension of UIServer for implementations
+// that can report the address they listen on. When implemented, the address is
+// probed to determine whether the UI is ready to accept connections.
+type AddressableUIServer interface {
+	UIServer
+	Addr() string
 }
 
-var SupportedPragmas = map[string]struct{}{
-	"journal_mode": {},
-	"synchronous":  {},
+// RebindableUIServer is an optional extension of UIServer for implementations
+// that can be recreated to listen on a different port. It is required by the
+// UIRetryOnAnotherPort failure policy.
+type RebindableUIServer interface {
+	UIServer
+	WithPort(port int) (UIServer, error)
 }
 
-func GetAllowedPragmas() []string {
-	var allowedPragmaList []string
-	for k := range SupportedPragmas {
-		allowedPragmaList = append(allowedPragmaList, k)
-	}
-	sort.Strings(allowedPragmaList)
-	return allowedPragmaList
+// DescribableUIServer is an optional extension of UIServer for implementations
+// that can report their configuration. When implemented, the configuration is
+// included in the server's effective configuration.
+type DescribableUIServer interface {
+	UIServer
+	Config() interface{}
+}
+
+// UIFailurePolicy determines how the server reacts when the UI server fails to start.
+type UIFailurePolicy int
+
+const (
+	// UIFailFast aborts server startup when the UI server fails to start.
+	UIFailFast UIFailurePolicy = iota
+	// UILogAndContinue logs the failure and runs the server headless.
+	UILogAndContinue
+	// UIRetryOnAnotherPort restarts the UI server on a system-chosen port,
+	// falling back to UILogAndContinue if that fails too.
+	UIRetryOnAnotherPort
+)
+
+// NamespaceSpec describes a namespace to register on start.
+//
+// Zero values leave the corresponding setting alone: new namespaces get the
+// default, and existing namespaces keep their current value.
+type NamespaceSpec struct {
+	Name        string
+	Description string
+	OwnerEmail  string
+	Retention   time.Duration
+	// SearchAttributes are registered for the whole cluster, as search attributes
+	// are not scoped to a namespace.
+	SearchAttributes   map[string]enums.IndexedValueType
+	HistoryArchival    enums.ArchivalState
+	VisibilityArchival enums.ArchivalState
+}
+
+type Config struct {
+	Ephemeral                  bool
+	DatabaseFilePath           string
+	VisibilityDatabaseFilePath string
+	VisibilityEphemeral        bool
+	AutoMigrate                bool
+	InitialSnapshot            []byte
+	FrontendPort               int
+	MetricsPort                int
+	DynamicPorts               bool
+	HistoryShards              int32
+	ArchivalDir                string
+	MaintenanceInterval        time.Duration
+	Namespaces                 []string
+	NamespaceSpecs             []NamespaceSpec
+	SearchAttributes           map[string]enums.IndexedValueType
+	SQLitePragmas              map[string]string
+	Logger                     log.Logger
+	UpstreamOptions            []temporal.ServerOption
+	portProvider               *PortProvider
+	FrontendIP                 string
+	UIServer                   UIServer
+	UIFailurePolicy            UIFailurePolicy
+	BaseConfig                 *config.Config
+	DynamicConfig              dynamicconfig.StaticClient
+	DynamicConfigFile          *dynamicconfig.FileBasedClientConfig
 }
 
 func NewDefaultConfig() (*Config, error) {
@@ -86,9 +140,10 @@ func NewDefaultConfig() (*Config, error) {
 	return &Config{
 		Ephemeral:        false,
 		DatabaseFilePath: filepath.Join(userConfigDir, "temporalite", "db", "default.db"),
+		AutoMigrate:      true,
 		FrontendPort:     0,
 		MetricsPort:      0,
-		UIServer:         noopUIServer{},
+		UIServer:         NoopUIServer{},
 		DynamicPorts:     false,
 		Namespaces:       nil,
 		SQLitePragmas:    nil,
@@ -110,21 +165,16 @@ func Convert(cfg *Config) *config.Config {
 		}
 	}()
 
-	sqliteConfig := config.SQL{
-		PluginName:        sqlite.PluginName,
-		ConnectAttributes: make(map[string]string),
-		DatabaseName:      cfg.DatabaseFilePath,
+	sqliteConfig := newSQLiteConfig(cfg.DatabaseFilePath, cfg.Ephemeral, cfg.SQLitePragmas)
+	dataStores := map[string]config.DataStore{
+		PersistenceStoreName: {SQL: sqliteConfig},
 	}
-	if cfg.Ephemeral {
-		sqliteConfig.ConnectAttributes["mode"] = "memory"
-		sqliteConfig.ConnectAttributes["cache"] = "shared"
-		sqliteConfig.DatabaseName = fmt.Sprintf("%d", rand.Intn(9999999))
-	} else {
-		sqliteConfig.ConnectAttributes["mode"] = "rwc"
-	}
-
-	for k, v := range cfg.SQLitePragmas {
-		sqliteConfig.ConnectAttributes["_"+k] = v
+	visibilityStore := PersistenceStoreName
+	if cfg.VisibilityEphemeral || cfg.VisibilityDatabaseFilePath != "" {
+		visibilityStore = VisibilityStoreName
+		dataStores[VisibilityStoreName] = config.DataStore{
+			SQL: newSQLiteConfig(cfg.VisibilityDatabaseFilePath, cfg.VisibilityEphemeral, cfg.SQLitePragmas),
+		}
 	}
 
 	var pprofPort int
@@ -146,6 +196,11 @@ func Convert(cfg *Config) *config.Config {
 		pprofPort = cfg.FrontendPort + 201
 	}
 
+	numHistoryShards := cfg.HistoryShards
+	if numHistoryShards == 0 {
+		numHistoryShards = 1
+	}
+
 	baseConfig := cfg.BaseConfig
 	baseConfig.Global.Membership = config.Membership{
 		MaxJoinDuration:  30 * time.Second,
@@ -160,19 +215,17 @@ func Convert(cfg *Config) *config.Config {
 	baseConfig.Global.PProf = config.PProf{Port: pprofPort}
 	baseConfig.Persistence = config.Persistence{
 		DefaultStore:     PersistenceStoreName,
-		VisibilityStore:  PersistenceStoreName,
-		NumHistoryShards: 1,
-		DataStores: map[string]config.DataStore{
-			PersistenceStoreName: {SQL: &sqliteConfig},
-		},
+		VisibilityStore:  visibilityStore,
+		NumHistoryShards: numHistoryShards,
+		DataStores:       dataStores,
 	}
 	baseConfig.ClusterMetadata = &cluster.Config{
 		EnableGlobalNamespace:    false,
 		FailoverVersionIncrement: 10,
-		MasterClusterName:        "active",
-		CurrentClusterName:       "active",
+		MasterClusterName:        ClusterName,
+		CurrentClusterName:       ClusterName,
 		ClusterInformation: map[string]cluster.ClusterInformation{
-			"active": {
+			ClusterName: {
 				Enabled:                true,
 				InitialFailoverVersion: 1,
 				RPCAddress:             fmt.Sprintf("%s:%d", broadcastAddress, cfg.FrontendPort),
@@ -200,6 +253,9 @@ func Convert(cfg *Config) *config.Config {
 			Provider:   nil,
 		},
 	}
+	if cfg.DynamicConfigFile != nil {
+		baseConfig.DynamicConfigClient = cfg.DynamicConfigFile
+	}
 	baseConfig.PublicClient = config.PublicClient{
 		HostPort: fmt.Sprintf("%s:%d", broadcastAddress, cfg.FrontendPort),
 	}
@@ -213,9 +269,70 @@ func Convert(cfg *Config) *config.Config {
 			},
 		},
 	}
+	if cfg.ArchivalDir != "" {
+		filestore := &config.FilestoreArchiver{
+			FileMode: "0666",
+			DirMode:  "0766",
+		}
+		baseConfig.Archival = config.Archival{
+			History: config.HistoryArchival{
+				State:      "enabled",
+				EnableRead: true,
+				Provider:   &config.HistoryArchiverProvider{Filestore: filestore},
+			},
+			Visibility: config.VisibilityArchival{
+				State:      "enabled",
+				EnableRead: true,
+				Provider:   &config.VisibilityArchiverProvider{Filestore: filestore},
+			},
+		}
+		baseConfig.NamespaceDefaults.Archival = config.ArchivalNamespaceDefaults{
+			History: config.HistoryArchivalNamespaceDefaults{
+				State: "enabled",
+				URI:   historyArchivalURI(cfg.ArchivalDir),
+			},
+			Visibility: config.VisibilityArchivalNamespaceDefaults{
+				State: "enabled",
+				URI:   visibilityArchivalURI(cfg.ArchivalDir),
+			},
+		}
+	}
 	return baseConfig
 }
 
+// historyArchivalURI returns the URI of the filestore archiver directory holding
+// workflow histories archived under dir.
+func historyArchivalURI(dir string) string {
+	return "file://" + filepath.ToSlash(filepath.Join(dir, "history"))
+}
+
+// visibilityArchivalURI returns the URI of the filestore archiver directory holding
+// visibility records archived under dir.
+func visibilityArchivalURI(dir string) string {
+	return "file://" + filepath.ToSlash(filepath.Join(dir, "visibility"))
+}
+
+func newSQLiteConfig(path string, ephemeral bool, pragmas map[string]string) *config.SQL {
+	sqliteConfig := &config.SQL{
+		PluginName:        sqlite.PluginName,
+		ConnectAttributes: make(map[string]string),
+		DatabaseName:      path,
+	}
+	if ephemeral {
+		sqliteConfig.ConnectAttributes["mode"] = "memory"
+		sqliteConfig.ConnectAttributes["cache"] = "shared"
+		sqliteConfig.DatabaseName = fmt.Sprintf("%d", rand.Intn(9999999))
+	} else {
+		sqliteConfig.ConnectAttributes["mode"] = "rwc"
+	}
+
+	// Connection attributes that are not SQLite URI parameters are applied as pragmas
+	for k, v := range pragmas {
+		sqliteConfig.ConnectAttributes[strings.ToLower(k)] = v
+	}
+	return sqliteConfig
+}
+
 func (cfg *Config) mustGetService(frontendPortOffset int) config.Service {
 	svc := config.Service{
 		RPC: config.RPC{
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package liteconfig
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pragma validates the values of a SQLite pragma.
+type pragma struct {
+	// Allowed values, compared case-insensitively. Empty for numeric pragmas.
+	values []string
+	// Allowed range of numeric pragmas.
+	min, max int64
+}
+
+func enumPragma(values ...string) pragma {
+	return pragma{values: values}
+}
+
+func intPragma(min, max int64) pragma {
+	return pragma{min: min, max: max}
+}
+
+var booleanValues = []string{"on", "off", "true", "false", "yes", "no", "1", "0"}
+
+// SupportedPragmas lists the SQLite pragmas that can be set with WithSQLitePragmas.
+//
+// See https://www.sqlite.org/pragma.html for their meaning.
+var SupportedPragmas = map[string]pragma{
+	"auto_vacuum":        enumPragma("none", "full", "incremental", "0", "1", "2"),
+	"busy_timeout":       intPragma(0, math.MaxInt32),
+	"cache_size":         intPragma(math.MinInt32, math.MaxInt32),
+	"foreign_keys":       enumPragma(booleanValues...),
+	"journal_mode":       enumPragma("delete", "truncate", "persist", "memory", "wal", "off"),
+	"journal_size_limit": intPragma(-1, math.MaxInt64),
+	"locking_mode":       enumPragma("normal", "exclusive"),
+	"mmap_size":          intPragma(0, math.MaxInt64),
+	"page_size":          enumPragma("512", "1024", "2048", "4096", "8192", "16384", "32768", "65536"),
+	"synchronous":        enumPragma("off", "normal", "full", "extra", "0", "1", "2", "3"),
+	"temp_store":         enumPragma("default", "file", "memory", "0", "1", "2"),
+	"wal_autocheckpoint": intPragma(0, math.MaxInt32),
+}
+
+func GetAllowedPragmas() []string {
+	var allowedPragmaList []string
+	for k := range SupportedPragmas {
+		allowedPragmaList = append(allowedPragmaList, k)
+	}
+	sort.Strings(allowedPragmaList)
+	return allowedPragmaList
+}
+
+// ValidatePragma returns an error describing the valid values if name is not a
+// supported pragma or value is not valid for it.
+func ValidatePragma(name, value string) error {
+	p, ok := SupportedPragmas[strings.ToLower(name)]
+	if !ok {
+		return fmt.Errorf("unsupported pragma %q, %v allowed", name, GetAllowedPragmas())
+	}
+
+	if len(p.values) > 0 {
+		for _, v := range p.values {
+			if strings.EqualFold(v, value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid value %q for pragma %q, must be one of: %s", value, name, strings.Join(p.values, ", "))
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < p.min || n > p.max {
+		return fmt.Errorf("invalid value %q for pragma %q, must be an integer between %d and %d", value, name, p.min, p.max)
+	}
+	return nil
+}
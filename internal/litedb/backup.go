@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+// Package litedb implements maintenance operations on Temporalite's SQLite databases.
+package litedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	sqliteplugin "go.temporal.io/server/common/persistence/sql/sqlplugin/sqlite"
+	"go.temporal.io/server/common/resolver"
+
+	"github.com/temporalio/temporalite/internal/schema"
+)
+
+// How long to wait for locks held by a running server, in milliseconds.
+const busyTimeout = "10000"
+
+// NewFileConfig returns the config used to access a database file outside of a
+// running server.
+func NewFileConfig(path string, readOnly bool) *config.SQL {
+	mode := "rw"
+	if readOnly {
+		mode = "ro"
+	}
+	return &config.SQL{
+		PluginName:   sqliteplugin.PluginName,
+		DatabaseName: path,
+		ConnectAttributes: map[string]string{
+			"mode":         mode,
+			"busy_timeout": busyTimeout,
+		},
+	}
+}
+
+// Exec runs statements against the database described by cfg.
+//
+// The SQLite plugin shares a single connection per database between all users in
+// the process, so statements are serialized with the queries of a server running
+// in the same process.
+func Exec(cfg *config.SQL, stmts ...string) error {
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backup writes a consistent copy of the database described by cfg to w.
+//
+// The copy is made with VACUUM INTO, which reads the database in a single
+// transaction and can therefore be used while a server is writing to it.
+func Backup(ctx context.Context, cfg *config.SQL, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "temporalite-backup-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "backup.db")
+	if err := BackupToFile(ctx, cfg, path); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	_, err = io.Copy(w, &contextReader{ctx: ctx, r: f})
+	return err
+}
+
+// BackupToFile writes a consistent copy of the database described by cfg to a
+// new file at path.
+func BackupToFile(ctx context.Context, cfg *config.SQL, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup file %q already exists", path)
+	}
+	if err := Exec(cfg, fmt.Sprintf("VACUUM INTO '%s'", strings.ReplaceAll(path, "'", "''"))); err != nil {
+		return fmt.Errorf("unable to back up database: %w", err)
+	}
+	return ctx.Err()
+}
+
+// Restore replaces the database file at path with the backup at backupPath.
+//
+// The backup's schema version is validated first: backups created by a newer
+// version of Temporalite are refused, while older ones are migrated on the next
+// server start. The restore is refused while a server holds the lock taken by
+// Lock on the database at path.
+func Restore(backupPath, path string) error {
+	if _, err := os.Stat(backupPath); err != nil {
+		return err
+	}
+	release, err := lockExclusive(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+	if err := schema.CheckFileVersion(backupPath); err != nil {
+		var versionErr *schema.VersionError
+		if !errors.As(err, &versionErr) || versionErr.Newer() {
+			return fmt.Errorf("invalid backup %q: %w", backupPath, err)
+		}
+	}
+
+	// Copy next to the destination first so that it can be replaced atomically
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	defer func() { _ = src.Close() }()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// Journal files of the replaced database would otherwise be applied to the backup
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence"
+	"go.temporal.io/server/common/persistence/serialization"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	"go.temporal.io/server/common/resolver"
+)
+
+// HistoryShardCount returns the number of history shards recorded for clusterName
+// when the cluster was first started with the database described by cfg.
+//
+// ok is false if the cluster has never been started with the database.
+func HistoryShardCount(cfg *config.SQL, clusterName string) (count int32, ok bool, err error) {
+	db, err := sql.NewSQLDB(sqlplugin.DbKindMain, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	row, err := db.GetClusterMetadata(context.Background(), &sqlplugin.ClusterMetadataFilter{ClusterName: clusterName})
+	if errors.Is(err, stdsql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to read cluster metadata: %w", err)
+	}
+
+	metadata, err := serialization.NewSerializer().DeserializeClusterMetadata(persistence.NewDataBlob(row.Data, row.DataEncoding))
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to decode cluster metadata: %w", err)
+	}
+	return metadata.HistoryShardCount, true, nil
+}
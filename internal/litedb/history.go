@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"context"
+	"fmt"
+
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/server/common"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/dynamicconfig"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/persistence"
+	"go.temporal.io/server/common/persistence/serialization"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/versionhistory"
+	"go.temporal.io/server/common/resolver"
+)
+
+// Number of history batches read at a time.
+const historyPageSize = 100
+
+// GetWorkflowHistory returns the event history of a workflow from the database
+// described by cfg. The current run of the workflow is read when runID is empty.
+func GetWorkflowHistory(cfg *config.SQL, clusterName, namespace, workflowID, runID string) (*historypb.History, error) {
+	detail, ok, err := GetNamespace(cfg, clusterName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("namespace %q not found", namespace)
+	}
+	namespaceID := detail.GetInfo().GetId()
+
+	numShards, ok, err := HistoryShardCount(cfg, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("cluster %q not found", clusterName)
+	}
+	shardID := common.WorkflowIDToHistoryShard(namespaceID, workflowID, numShards)
+
+	var history historypb.History
+	err = withExecutionManager(cfg, clusterName, func(ctx context.Context, m persistence.ExecutionManager) error {
+		if runID == "" {
+			resp, err := m.GetCurrentExecution(ctx, &persistence.GetCurrentExecutionRequest{
+				ShardID:     shardID,
+				NamespaceID: namespaceID,
+				WorkflowID:  workflowID,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to read workflow: %w", err)
+			}
+			runID = resp.RunID
+		}
+
+		resp, err := m.GetWorkflowExecution(ctx, &persistence.GetWorkflowExecutionRequest{
+			ShardID:     shardID,
+			NamespaceID: namespaceID,
+			WorkflowID:  workflowID,
+			RunID:       runID,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to read workflow: %w", err)
+		}
+		versionHistory, err := versionhistory.GetCurrentVersionHistory(resp.State.GetExecutionInfo().GetVersionHistories())
+		if err != nil {
+			return fmt.Errorf("unable to read workflow: %w", err)
+		}
+
+		req := &persistence.ReadHistoryBranchRequest{
+			ShardID:     shardID,
+			BranchToken: versionHistory.GetBranchToken(),
+			MinEventID:  common.FirstEventID,
+			MaxEventID:  resp.State.GetNextEventId(),
+			PageSize:    historyPageSize,
+		}
+		for {
+			events, _, token, err := persistence.ReadFullPageEvents(ctx, m, req)
+			if err != nil {
+				return fmt.Errorf("unable to read history: %w", err)
+			}
+			history.Events = append(history.Events, events...)
+			if len(token) == 0 {
+				return nil
+			}
+			req.NextPageToken = token
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+func withExecutionManager(cfg *config.SQL, clusterName string, fn func(context.Context, persistence.ExecutionManager) error) error {
+	factory := sql.NewFactory(*cfg, resolver.NewNoopResolver(), clusterName, log.NewNoopLogger())
+	defer factory.Close()
+
+	store, err := factory.NewExecutionStore()
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	// Nothing is written, so the transaction size limit doesn't matter
+	m := persistence.NewExecutionManager(store, serialization.NewSerializer(), log.NewNoopLogger(), dynamicconfig.GetIntPropertyFn(0))
+	defer m.Close()
+
+	return fn(context.Background(), m)
+}
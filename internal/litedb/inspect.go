@@ -0,0 +1,303 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	stdsql "database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/server/common/persistence"
+	"go.temporal.io/server/common/persistence/serialization"
+
+	"github.com/temporalio/temporalite/internal/schema"
+)
+
+// Inspection summarizes the contents of a database file.
+type Inspection struct {
+	SchemaVersions map[string]string     `json:"schemaVersions"`
+	Files          []FileInspection      `json:"files"`
+	Namespaces     []NamespaceInspection `json:"namespaces"`
+	TaskQueues     []TaskQueueInspection `json:"taskQueues"`
+	Shards         []ShardInspection     `json:"shards"`
+}
+
+// FileInspection reports the size of a database file and of its write-ahead log.
+type FileInspection struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	WALSize int64  `json:"walSize"`
+}
+
+// NamespaceInspection reports the number of workflows of a namespace by status.
+//
+// Name is empty for workflows whose namespace no longer exists.
+type NamespaceInspection struct {
+	Name      string           `json:"name"`
+	ID        string           `json:"id"`
+	State     string           `json:"state"`
+	Workflows map[string]int64 `json:"workflows"`
+}
+
+// TaskQueueInspection reports the number of tasks waiting to be dispatched from a
+// task queue.
+type TaskQueueInspection struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Kind      string `json:"kind"`
+	AckLevel  int64  `json:"ackLevel"`
+	Backlog   int64  `json:"backlog"`
+}
+
+// ShardInspection reports the ownership of a history shard.
+type ShardInspection struct {
+	ID         int32      `json:"id"`
+	RangeID    int64      `json:"rangeId"`
+	Owner      string     `json:"owner"`
+	UpdateTime *time.Time `json:"updateTime"`
+}
+
+// Inspect summarizes the database file at path. Workflows are counted from the
+// visibility records at visibilityPath, which may be the same file as path.
+//
+// The files are only read, so they can be inspected while a server is running,
+// although the figures may then be slightly out of date.
+func Inspect(clusterName, path, visibilityPath string) (*Inspection, error) {
+	paths := []string{path}
+	if visibilityPath != path {
+		paths = append(paths, visibilityPath)
+	}
+	inspection := &Inspection{}
+	for _, p := range paths {
+		file, err := inspectFile(p)
+		if err != nil {
+			return nil, err
+		}
+		inspection.Files = append(inspection.Files, file)
+	}
+
+	cfg := NewFileConfig(path, true)
+	versions, err := schema.Version(cfg)
+	if err != nil {
+		return nil, err
+	}
+	inspection.SchemaVersions = versions
+
+	details, err := ListNamespaces(cfg, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(details))
+	for _, detail := range details {
+		names[detail.GetInfo().GetId()] = detail.GetInfo().GetName()
+	}
+
+	db, err := openFile(path, true)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	if inspection.TaskQueues, err = inspectTaskQueues(db, names); err != nil {
+		return nil, err
+	}
+	if inspection.Shards, err = inspectShards(db); err != nil {
+		return nil, err
+	}
+
+	visibilityDB := db
+	if visibilityPath != path {
+		if visibilityDB, err = openFile(visibilityPath, true); err != nil {
+			return nil, err
+		}
+		defer func() { _ = visibilityDB.Close() }()
+	}
+	counts, err := countWorkflows(visibilityDB)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		id := detail.GetInfo().GetId()
+		inspection.Namespaces = append(inspection.Namespaces, NamespaceInspection{
+			Name:      detail.GetInfo().GetName(),
+			ID:        id,
+			State:     detail.GetInfo().GetState().String(),
+			Workflows: counts[id],
+		})
+		delete(counts, id)
+	}
+	var orphans []string
+	for id := range counts {
+		orphans = append(orphans, id)
+	}
+	sort.Strings(orphans)
+	for _, id := range orphans {
+		inspection.Namespaces = append(inspection.Namespaces, NamespaceInspection{
+			ID:        id,
+			Workflows: counts[id],
+		})
+	}
+	return inspection, nil
+}
+
+func inspectFile(path string) (FileInspection, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return FileInspection{}, err
+	}
+	file := FileInspection{Path: path, Size: info.Size()}
+	if info, err := os.Stat(path + "-wal"); err == nil {
+		file.WALSize = info.Size()
+	} else if !os.IsNotExist(err) {
+		return FileInspection{}, err
+	}
+	return file, nil
+}
+
+// openFile opens the database file at path. Unlike connections of the SQLite
+// persistence plugin, it must be closed by the caller.
+func openFile(path string, readOnly bool) (*stdsql.DB, error) {
+	mode := "rw"
+	if readOnly {
+		mode = "ro"
+	}
+	query := url.Values{
+		"mode":    {mode},
+		"_pragma": {"busy_timeout(" + busyTimeout + ")"},
+	}
+	db, err := stdsql.Open("sqlite", fmt.Sprintf("file:%s?%s", path, query.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open database: %w", err)
+	}
+	return db, nil
+}
+
+// countWorkflows returns the number of workflows by namespace ID and status.
+func countWorkflows(db *stdsql.DB) (map[string]map[string]int64, error) {
+	rows, err := db.Query("SELECT namespace_id, status, COUNT(*) FROM executions_visibility GROUP BY namespace_id, status")
+	if err != nil {
+		return nil, fmt.Errorf("unable to count workflows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]map[string]int64)
+	for rows.Next() {
+		var (
+			namespaceID string
+			status      int32
+			count       int64
+		)
+		if err := rows.Scan(&namespaceID, &status, &count); err != nil {
+			return nil, fmt.Errorf("unable to count workflows: %w", err)
+		}
+		if counts[namespaceID] == nil {
+			counts[namespaceID] = make(map[string]int64)
+		}
+		counts[namespaceID][enumspb.WorkflowExecutionStatus(status).String()] = count
+	}
+	return counts, rows.Err()
+}
+
+// inspectTaskQueues returns the task queues sorted by namespace, name and type.
+func inspectTaskQueues(db *stdsql.DB, names map[string]string) ([]TaskQueueInspection, error) {
+	rows, err := db.Query("SELECT range_hash, task_queue_id, data, data_encoding FROM task_queues")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read task queues: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	type key struct {
+		rangeHash   uint32
+		taskQueueID []byte
+	}
+	var (
+		serializer = serialization.NewSerializer()
+		queues     []TaskQueueInspection
+		keys       []key
+	)
+	for rows.Next() {
+		var (
+			k        key
+			data     []byte
+			encoding string
+		)
+		if err := rows.Scan(&k.rangeHash, &k.taskQueueID, &data, &encoding); err != nil {
+			return nil, fmt.Errorf("unable to read task queues: %w", err)
+		}
+		info, err := serializer.TaskQueueInfoFromBlob(persistence.NewDataBlob(data, encoding))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode task queue: %w", err)
+		}
+		queues = append(queues, TaskQueueInspection{
+			Namespace: names[info.GetNamespaceId()],
+			Name:      info.GetName(),
+			Type:      info.GetTaskType().String(),
+			Kind:      info.GetKind().String(),
+			AckLevel:  info.GetAckLevel(),
+		})
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read task queues: %w", err)
+	}
+	_ = rows.Close()
+
+	// Tasks at or below the ack level have been dispatched but not deleted yet
+	for i, k := range keys {
+		err := db.QueryRow("SELECT COUNT(*) FROM tasks WHERE range_hash = ? AND task_queue_id = ? AND task_id > ?",
+			k.rangeHash, k.taskQueueID, queues[i].AckLevel).Scan(&queues[i].Backlog)
+		if err != nil {
+			return nil, fmt.Errorf("unable to count tasks: %w", err)
+		}
+	}
+
+	sort.Slice(queues, func(i, j int) bool {
+		a, b := queues[i], queues[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Type < b.Type
+	})
+	return queues, nil
+}
+
+func inspectShards(db *stdsql.DB) ([]ShardInspection, error) {
+	rows, err := db.Query("SELECT data, data_encoding FROM shards ORDER BY shard_id")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read shards: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	serializer := serialization.NewSerializer()
+	var shards []ShardInspection
+	for rows.Next() {
+		var (
+			data     []byte
+			encoding string
+		)
+		if err := rows.Scan(&data, &encoding); err != nil {
+			return nil, fmt.Errorf("unable to read shards: %w", err)
+		}
+		info, err := serializer.ShardInfoFromBlob(persistence.NewDataBlob(data, encoding))
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode shard: %w", err)
+		}
+		shards = append(shards, ShardInspection{
+			ID:         info.GetShardId(),
+			RangeID:    info.GetRangeId(),
+			Owner:      info.GetOwner(),
+			UpdateTime: info.GetUpdateTime(),
+		})
+	}
+	return shards, rows.Err()
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"fmt"
+	"strings"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	"go.temporal.io/server/common/resolver"
+
+	"github.com/temporalio/temporalite/internal/schema"
+)
+
+// Alias of the source database while it is attached.
+const loadSchema = "source"
+
+// Load replaces the rows of every table in the database described by cfg with
+// the rows of the same table in the database file at path.
+//
+// The file must have the schema version expected by this build. The database
+// described by cfg must already have a schema, which is the case for in-memory
+// databases once opened by the SQLite plugin.
+func Load(cfg *config.SQL, path string) error {
+	if err := schema.CheckFileVersion(path); err != nil {
+		return fmt.Errorf("invalid database %q: %w", path, err)
+	}
+
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tables, err := db.ListTables(cfg.DatabaseName)
+	if err != nil {
+		return fmt.Errorf("unable to list tables: %w", err)
+	}
+
+	// The plugin holds a single connection per database, so the attachment is
+	// visible to every statement below.
+	if err := db.Exec(fmt.Sprintf("ATTACH DATABASE '%s' AS %s", strings.ReplaceAll(path, "'", "''"), loadSchema)); err != nil {
+		return fmt.Errorf("unable to attach %q: %w", path, err)
+	}
+	defer func() { _ = db.Exec("DETACH DATABASE " + loadSchema) }()
+
+	if err := db.Exec("BEGIN"); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		for _, stmt := range []string{
+			fmt.Sprintf("DELETE FROM main.%s", table),
+			fmt.Sprintf("INSERT INTO main.%s SELECT * FROM %s.%s", table, loadSchema, table),
+		} {
+			if err := db.Exec(stmt); err != nil {
+				_ = db.Exec("ROLLBACK")
+				return fmt.Errorf("unable to copy table %s: %w", table, err)
+			}
+		}
+	}
+	return db.Exec("COMMIT")
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errLocked is returned by lockFile when the lock is held in a conflicting mode.
+var errLocked = errors.New("locked")
+
+// Lock marks the database file at path as in use until the returned function is
+// called, so that it cannot be restored in the meantime. Any number of servers
+// may hold the lock at the same time.
+//
+// SQLite only locks database files for the duration of transactions, so an idle
+// server holds no SQLite lock. The lock is held on a separate file next to the
+// database instead: closing any descriptor of the database file would release
+// the SQLite locks of the process.
+func Lock(path string) (release func() error, err error) {
+	release, err = lockFile(lockFilePath(path), false)
+	if errors.Is(err, errLocked) {
+		return nil, fmt.Errorf("database %q is being restored", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock database %q: %w", path, err)
+	}
+	return release, nil
+}
+
+// lockExclusive locks the database file at path for the exclusive use of the
+// caller, failing if a server holds the lock taken by Lock.
+func lockExclusive(path string) (release func() error, err error) {
+	release, err = lockFile(lockFilePath(path), true)
+	if errors.Is(err, errLocked) {
+		return nil, fmt.Errorf("database %q is in use by a running server", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to lock database %q: %w", path, err)
+	}
+	return release, nil
+}
+
+func lockFilePath(path string) string {
+	return path + ".lock"
+}
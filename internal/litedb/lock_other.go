@@ -0,0 +1,14 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+//go:build !unix
+
+package litedb
+
+// lockFile does nothing. On Windows, SQLite keeps database files open without
+// allowing them to be deleted, so replacing the database of a running server
+// fails on its own.
+func lockFile(path string, exclusive bool) (func() error, error) {
+	return func() error { return nil }, nil
+}
@@ -0,0 +1,38 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+//go:build unix
+
+package litedb
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile takes a shared or exclusive flock on the file at path, creating it if
+// needed. It does not wait for conflicting locks to be released.
+//
+// The file is left in place once unlocked: removing it would let two processes
+// lock different files under the same path.
+func lockFile(path string, exclusive bool) (func() error, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	how := syscall.LOCK_SH
+	if exclusive {
+		how = syscall.LOCK_EX
+	}
+	if err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	// Closing the file releases the lock
+	return f.Close, nil
+}
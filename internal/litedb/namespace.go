@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	persistencespb "go.temporal.io/server/api/persistence/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/persistence"
+	"go.temporal.io/server/common/persistence/serialization"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/primitives/timestamp"
+	"go.temporal.io/server/common/resolver"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+)
+
+// GetNamespace returns the namespace called name from the database described by cfg.
+//
+// ok is false if the namespace does not exist.
+func GetNamespace(cfg *config.SQL, clusterName, name string) (detail *persistencespb.NamespaceDetail, ok bool, err error) {
+	err = withMetadataManager(cfg, clusterName, func(ctx context.Context, m persistence.MetadataManager) error {
+		resp, err := m.GetNamespace(ctx, &persistence.GetNamespaceRequest{Name: name})
+		var notFound *serviceerror.NamespaceNotFound
+		if errors.As(err, &notFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read namespace: %w", err)
+		}
+		detail, ok = resp.Namespace, true
+		return nil
+	})
+	return detail, ok, err
+}
+
+// ListNamespaces returns all namespaces from the database described by cfg.
+func ListNamespaces(cfg *config.SQL, clusterName string) ([]*persistencespb.NamespaceDetail, error) {
+	var namespaces []*persistencespb.NamespaceDetail
+	err := withMetadataManager(cfg, clusterName, func(ctx context.Context, m persistence.MetadataManager) error {
+		var token []byte
+		for {
+			resp, err := m.ListNamespaces(ctx, &persistence.ListNamespacesRequest{
+				PageSize:      100,
+				NextPageToken: token,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to list namespaces: %w", err)
+			}
+			for _, ns := range resp.Namespaces {
+				namespaces = append(namespaces, ns.Namespace)
+			}
+			if token = resp.NextPageToken; len(token) == 0 {
+				return nil
+			}
+		}
+	})
+	return namespaces, err
+}
+
+// CreateNamespace adds a local namespace to the database described by cfg.
+func CreateNamespace(cfg *config.SQL, clusterName string, detail *persistencespb.NamespaceDetail) error {
+	return withMetadataManager(cfg, clusterName, func(ctx context.Context, m persistence.MetadataManager) error {
+		if _, err := m.CreateNamespace(ctx, &persistence.CreateNamespaceRequest{Namespace: detail}); err != nil {
+			return fmt.Errorf("unable to create namespace: %w", err)
+		}
+		return nil
+	})
+}
+
+// UpdateNamespace replaces a local namespace in the database described by cfg.
+//
+// The server must not be running, as it only picks up namespace changes it was
+// notified about.
+func UpdateNamespace(cfg *config.SQL, clusterName string, detail *persistencespb.NamespaceDetail) error {
+	return withMetadataManager(cfg, clusterName, func(ctx context.Context, m persistence.MetadataManager) error {
+		metadata, err := m.GetMetadata(ctx)
+		if err != nil {
+			return fmt.Errorf("unable to read namespace metadata: %w", err)
+		}
+		if err := m.UpdateNamespace(ctx, &persistence.UpdateNamespaceRequest{
+			Namespace:           detail,
+			NotificationVersion: metadata.NotificationVersion,
+		}); err != nil {
+			return fmt.Errorf("unable to update namespace: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteNamespace removes the namespace called name from the database described by cfg.
+//
+// Only the namespace record is removed: the workflows of the namespace are left in place.
+func DeleteNamespace(cfg *config.SQL, clusterName, name string) error {
+	return withMetadataManager(cfg, clusterName, func(ctx context.Context, m persistence.MetadataManager) error {
+		if _, err := m.GetNamespace(ctx, &persistence.GetNamespaceRequest{Name: name}); err != nil {
+			return fmt.Errorf("unable to read namespace: %w", err)
+		}
+		if err := m.DeleteNamespaceByName(ctx, &persistence.DeleteNamespaceByNameRequest{Name: name}); err != nil {
+			return fmt.Errorf("unable to delete namespace: %w", err)
+		}
+		return nil
+	})
+}
+
+// ApplyNamespaceSpec updates detail with the settings set in spec. Archival URIs that
+// are not set yet are taken from defaults.
+func ApplyNamespaceSpec(detail *persistencespb.NamespaceDetail, spec liteconfig.NamespaceSpec, defaults config.ArchivalNamespaceDefaults) {
+	if spec.Description != "" {
+		detail.Info.Description = spec.Description
+	}
+	if spec.OwnerEmail != "" {
+		detail.Info.Owner = spec.OwnerEmail
+	}
+	if spec.Retention != 0 {
+		detail.Config.Retention = timestamp.DurationPtr(spec.Retention)
+	}
+	if spec.HistoryArchival != enumspb.ARCHIVAL_STATE_UNSPECIFIED {
+		detail.Config.HistoryArchivalState = spec.HistoryArchival
+		if detail.Config.HistoryArchivalUri == "" {
+			detail.Config.HistoryArchivalUri = defaults.History.URI
+		}
+	}
+	if spec.VisibilityArchival != enumspb.ARCHIVAL_STATE_UNSPECIFIED {
+		detail.Config.VisibilityArchivalState = spec.VisibilityArchival
+		if detail.Config.VisibilityArchivalUri == "" {
+			detail.Config.VisibilityArchivalUri = defaults.Visibility.URI
+		}
+	}
+}
+
+func withMetadataManager(cfg *config.SQL, clusterName string, fn func(context.Context, persistence.MetadataManager) error) error {
+	factory := sql.NewFactory(*cfg, resolver.NewNoopResolver(), clusterName, log.NewNoopLogger())
+	defer factory.Close()
+
+	store, err := factory.NewMetadataStore()
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	m := persistence.NewMetadataManagerImpl(store, serialization.NewSerializer(), log.NewNoopLogger(), clusterName)
+	defer m.Close()
+
+	return fn(context.Background(), m)
+}
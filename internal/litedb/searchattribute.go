@@ -0,0 +1,80 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"context"
+	"fmt"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	persistencespb "go.temporal.io/server/api/persistence/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/log"
+	"go.temporal.io/server/common/persistence"
+	"go.temporal.io/server/common/persistence/serialization"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/resolver"
+)
+
+// Search attributes are registered under an empty index name when Elasticsearch is not used.
+const visibilityIndexName = ""
+
+// AddSearchAttributes registers custom search attributes for clusterName in the database
+// described by cfg. Attributes that are already registered are left as they are, but
+// must have the same type.
+//
+// The cluster must have been started with the database at least once.
+func AddSearchAttributes(cfg *config.SQL, clusterName string, attributes map[string]enumspb.IndexedValueType) error {
+	factory := sql.NewFactory(*cfg, resolver.NewNoopResolver(), clusterName, log.NewNoopLogger())
+	defer factory.Close()
+
+	store, err := factory.NewClusterMetadataStore()
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	m := persistence.NewClusterMetadataManagerImpl(store, serialization.NewSerializer(), clusterName, log.NewNoopLogger())
+	defer m.Close()
+
+	ctx := context.Background()
+	resp, err := m.GetCurrentClusterMetadata(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to read cluster metadata: %w", err)
+	}
+
+	metadata := resp.ClusterMetadata
+	if metadata.IndexSearchAttributes == nil {
+		metadata.IndexSearchAttributes = make(map[string]*persistencespb.IndexSearchAttributes)
+	}
+	index := metadata.IndexSearchAttributes[visibilityIndexName]
+	if index == nil {
+		index = &persistencespb.IndexSearchAttributes{}
+		metadata.IndexSearchAttributes[visibilityIndexName] = index
+	}
+	if index.CustomSearchAttributes == nil {
+		index.CustomSearchAttributes = make(map[string]enumspb.IndexedValueType)
+	}
+
+	var changed bool
+	for name, t := range attributes {
+		existing, ok := index.CustomSearchAttributes[name]
+		if !ok {
+			index.CustomSearchAttributes[name] = t
+			changed = true
+		} else if existing != t {
+			return fmt.Errorf("search attribute %q is already registered with type %s", name, existing)
+		}
+	}
+	if !changed {
+		return nil
+	}
+
+	if _, err := m.SaveClusterMetadata(ctx, &persistence.SaveClusterMetadataRequest{
+		ClusterMetadata: metadata,
+		Version:         resp.Version,
+	}); err != nil {
+		return fmt.Errorf("unable to save cluster metadata: %w", err)
+	}
+	return nil
+}
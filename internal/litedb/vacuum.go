@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package litedb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	"go.temporal.io/server/common/resolver"
+)
+
+const (
+	// Value of PRAGMA auto_vacuum for incremental auto-vacuum.
+	autoVacuumIncremental = 2
+	// Offset of the total number of freelist pages in the database header.
+	freelistCountOffset = 36
+	// Maximum number of pages freed per transaction by incrementalVacuum.
+	vacuumBatchSize = 1024
+)
+
+// EnableIncrementalVacuum switches the database described by cfg to incremental
+// auto-vacuum, so that the space freed by deletions can be returned to the file
+// system with IncrementalVacuum.
+//
+// Switching an existing database rebuilds it with VACUUM, which takes a while for
+// large files. Databases which already use incremental auto-vacuum are left alone.
+func EnableIncrementalVacuum(cfg *config.SQL) error {
+	db, err := openFile(cfg.DatabaseName, true)
+	if err != nil {
+		return err
+	}
+	var mode int
+	err = db.QueryRow("PRAGMA auto_vacuum").Scan(&mode)
+	_ = db.Close()
+	if err != nil {
+		return fmt.Errorf("unable to read auto-vacuum mode: %w", err)
+	}
+	if mode == autoVacuumIncremental {
+		return nil
+	}
+
+	if err := Exec(cfg, "PRAGMA auto_vacuum = INCREMENTAL", "VACUUM"); err != nil {
+		return fmt.Errorf("unable to enable incremental auto-vacuum: %w", err)
+	}
+	return nil
+}
+
+// IncrementalVacuum returns the free pages of the database described by cfg to
+// the file system, then writes the WAL back into the database file. The database
+// must use incremental auto-vacuum for its file to shrink.
+//
+// It is safe to call while the server is running.
+func IncrementalVacuum(cfg *config.SQL) error {
+	// The size of the freelist is read from the database header, which is only up
+	// to date once the WAL has been written back into the database file
+	if err := Checkpoint(cfg); err != nil {
+		return fmt.Errorf("unable to checkpoint WAL: %w", err)
+	}
+	pages, err := freelistCount(cfg.DatabaseName)
+	if err != nil {
+		return fmt.Errorf("unable to read freelist size: %w", err)
+	}
+	if err := incrementalVacuum(cfg, pages); err != nil {
+		return fmt.Errorf("unable to vacuum database: %w", err)
+	}
+	if err := Checkpoint(cfg); err != nil {
+		return fmt.Errorf("unable to checkpoint WAL: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint writes the content of the WAL of the database described by cfg back
+// into the database file and truncates the WAL. It is a no-op for databases that
+// do not use WAL journaling.
+func Checkpoint(cfg *config.SQL) error {
+	return Exec(cfg, "PRAGMA wal_checkpoint(TRUNCATE)")
+}
+
+// Compact rebuilds the database described by cfg with VACUUM so that it takes as
+// little space as possible, whatever its auto-vacuum mode.
+//
+// VACUUM needs exclusive access to the database, so compaction is refused while a
+// server holds the lock taken by Lock on the database.
+func Compact(cfg *config.SQL) error {
+	release, err := lockExclusive(cfg.DatabaseName)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = release() }()
+
+	if err := Exec(cfg, "VACUUM"); err != nil {
+		return fmt.Errorf("unable to compact database: %w", err)
+	}
+	if err := Checkpoint(cfg); err != nil {
+		return fmt.Errorf("unable to checkpoint WAL: %w", err)
+	}
+	return nil
+}
+
+// incrementalVacuum frees up to pages pages of the database described by cfg.
+//
+// PRAGMA incremental_vacuum frees a single page per step, and the SQLite driver
+// steps each statement once, so the pragma is repeated once per page. Statements
+// go through the plugin's shared connection like those of Exec.
+func incrementalVacuum(cfg *config.SQL, pages uint32) error {
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	for pages > 0 {
+		n := pages
+		if n > vacuumBatchSize {
+			n = vacuumBatchSize
+		}
+		// A single call keeps other users of the connection out of the transaction
+		if err := db.Exec("BEGIN;" + strings.Repeat("PRAGMA incremental_vacuum;", int(n)) + "COMMIT"); err != nil {
+			_ = db.Exec("ROLLBACK")
+			return err
+		}
+		pages -= n
+	}
+	return nil
+}
+
+// freelistCount returns the number of free pages recorded in the header of the
+// database file at path.
+func freelistCount(path string) (uint32, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var header [4]byte
+	if _, err := f.ReadAt(header[:], freelistCountOffset); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(header[:]), nil
+}
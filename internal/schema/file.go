@@ -0,0 +1,61 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package schema
+
+import (
+	stdsql "database/sql"
+	"fmt"
+	"net/url"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// CheckFileVersion is the same as CheckVersion for the database file at path.
+//
+// Unlike the SQLite persistence plugin, whose connections stay open until the
+// process exits, the file is closed before returning. Use it for files that are
+// only read once, such as backups and snapshots.
+func CheckFileVersion(path string) error {
+	db, err := stdsql.Open("sqlite", fmt.Sprintf("file:%s?%s", path, url.Values{"mode": {"ro"}}.Encode()))
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	versions, err := readVersions(&fileDB{db: db})
+	if err != nil {
+		return err
+	}
+	return checkVersions(versions)
+}
+
+// fileDB implements versionReader with the same queries as the SQLite plugin.
+type fileDB struct {
+	db *stdsql.DB
+}
+
+func (f *fileDB) ListTables(string) ([]string, error) {
+	rows, err := f.db.Query("SELECT name FROM sqlite_master WHERE type='table'")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func (f *fileDB) ReadSchemaVersion(database string) (string, error) {
+	var version string
+	err := f.db.QueryRow("SELECT curr_version FROM schema_version WHERE version_partition=0 AND db_name=?", database).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,258 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+// Package schema creates and upgrades the schema of Temporalite's SQLite databases.
+package schema
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	"go.temporal.io/server/common/resolver"
+	"go.temporal.io/server/schema/sqlite"
+)
+
+const (
+	schemaVersionTable = "schema_version"
+	// Schema version of databases created before versions were recorded.
+	// This was the only SQLite schema version released at the time.
+	baselineVersion = "0.1"
+)
+
+// migration upgrades a database to version from the version preceding it.
+type migration struct {
+	version     string
+	description string
+	statements  []string
+}
+
+// component is a part of the schema whose version is tracked independently.
+type component struct {
+	name       string
+	version    string
+	migrations []migration
+}
+
+// Migrations must be listed in ascending version order. Add an entry when a new
+// release of go.temporal.io/server changes the SQLite schema.
+var components = []component{
+	{
+		name:       "temporal",
+		version:    sqlite.Version,
+		migrations: nil,
+	},
+	{
+		name:       "temporal_visibility",
+		version:    sqlite.VisibilityVersion,
+		migrations: nil,
+	},
+}
+
+// VersionError is returned when a database schema version does not match the
+// version expected by this build of Temporalite.
+type VersionError struct {
+	Component string
+	Current   string
+	Expected  string
+}
+
+// Newer reports whether the database schema is newer than the expected version.
+func (e *VersionError) Newer() bool {
+	return compareVersions(e.Current, e.Expected) > 0
+}
+
+func (e *VersionError) Error() string {
+	if e.Newer() {
+		return fmt.Sprintf("%s schema version %s is newer than version %s supported by this build, please upgrade temporalite", e.Component, e.Current, e.Expected)
+	}
+	return fmt.Sprintf("%s schema version %s is older than version %s expected by this build and automatic migrations are disabled", e.Component, e.Current, e.Expected)
+}
+
+// Setup creates the schema in an empty database, or checks the schema version
+// of an existing database and applies any pending migrations when autoMigrate is true.
+//
+// A database with a schema newer than supported by this build is always refused.
+func Setup(cfg *config.SQL, autoMigrate bool) error {
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return fmt.Errorf("unable to create SQLite admin DB: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	tables, err := db.ListTables(cfg.DatabaseName)
+	if err != nil {
+		return fmt.Errorf("unable to list tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return create(db)
+	}
+
+	versioned := hasTable(tables, schemaVersionTable)
+	if !versioned && autoMigrate {
+		if err := db.CreateSchemaVersionTables(); err != nil {
+			return fmt.Errorf("unable to create schema version tables: %w", err)
+		}
+	}
+
+	for _, c := range components {
+		current := baselineVersion
+		if versioned {
+			if current, err = db.ReadSchemaVersion(c.name); err != nil {
+				return fmt.Errorf("unable to read %s schema version: %w", c.name, err)
+			}
+		} else if autoMigrate {
+			if err := db.UpdateSchemaVersion(c.name, current, current); err != nil {
+				return fmt.Errorf("unable to record %s schema version: %w", c.name, err)
+			}
+		}
+		if err := upgrade(db, c, current, autoMigrate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versionReader is the subset of sqlplugin.AdminDB used to read schema versions.
+type versionReader interface {
+	ListTables(database string) ([]string, error)
+	ReadSchemaVersion(database string) (string, error)
+}
+
+// Version returns the schema version of each component recorded in the database.
+//
+// Databases created before versions were recorded report the baseline version.
+func Version(cfg *config.SQL) (map[string]string, error) {
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create SQLite admin DB: %w", err)
+	}
+	defer func() { _ = db.Close() }()
+
+	return readVersions(db)
+}
+
+// CheckVersion returns a *VersionError if the database described by cfg does not
+// have the schema version expected by this build.
+func CheckVersion(cfg *config.SQL) error {
+	versions, err := Version(cfg)
+	if err != nil {
+		return err
+	}
+	return checkVersions(versions)
+}
+
+func readVersions(db versionReader) (map[string]string, error) {
+	// The database name argument is ignored by SQLite
+	tables, err := db.ListTables("")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("database has no schema")
+	}
+
+	versioned := hasTable(tables, schemaVersionTable)
+	versions := make(map[string]string, len(components))
+	for _, c := range components {
+		versions[c.name] = baselineVersion
+		if versioned {
+			if versions[c.name], err = db.ReadSchemaVersion(c.name); err != nil {
+				return nil, fmt.Errorf("unable to read %s schema version: %w", c.name, err)
+			}
+		}
+	}
+	return versions, nil
+}
+
+func checkVersions(versions map[string]string) error {
+	for _, c := range components {
+		if versions[c.name] != c.version {
+			return &VersionError{Component: c.name, Current: versions[c.name], Expected: c.version}
+		}
+	}
+	return nil
+}
+
+func create(db sqlplugin.AdminDB) error {
+	if err := sqlite.SetupSchemaOnDB(db); err != nil {
+		return err
+	}
+	if err := db.CreateSchemaVersionTables(); err != nil {
+		return fmt.Errorf("unable to create schema version tables: %w", err)
+	}
+	for _, c := range components {
+		if err := db.UpdateSchemaVersion(c.name, c.version, c.version); err != nil {
+			return fmt.Errorf("unable to record %s schema version: %w", c.name, err)
+		}
+		if err := db.WriteSchemaUpdateLog("0", c.version, "", "initial version"); err != nil {
+			return fmt.Errorf("unable to record %s schema update: %w", c.name, err)
+		}
+	}
+	return nil
+}
+
+func upgrade(db sqlplugin.AdminDB, c component, current string, autoMigrate bool) error {
+	switch cmp := compareVersions(current, c.version); {
+	case cmp == 0:
+		return nil
+	case cmp > 0 || !autoMigrate:
+		return &VersionError{Component: c.name, Current: current, Expected: c.version}
+	}
+
+	for _, m := range c.migrations {
+		if compareVersions(m.version, current) <= 0 || compareVersions(m.version, c.version) > 0 {
+			continue
+		}
+		for _, stmt := range m.statements {
+			if err := db.Exec(stmt); err != nil {
+				return fmt.Errorf("error migrating %s schema to version %s: executing statement %q: %w", c.name, m.version, stmt, err)
+			}
+		}
+		if err := db.UpdateSchemaVersion(c.name, m.version, m.version); err != nil {
+			return fmt.Errorf("unable to record %s schema version: %w", c.name, err)
+		}
+		if err := db.WriteSchemaUpdateLog(current, m.version, "", m.description); err != nil {
+			return fmt.Errorf("unable to record %s schema update: %w", c.name, err)
+		}
+		current = m.version
+	}
+
+	if current != c.version {
+		return fmt.Errorf("unable to migrate %s schema from version %s to %s: no migration available", c.name, current, c.version)
+	}
+	return nil
+}
+
+func hasTable(tables []string, name string) bool {
+	for _, table := range tables {
+		if table == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compareVersions compares dot-separated numeric versions, returning a negative
+// number, zero or a positive number when a is lower than, equal to or greater than b.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
@@ -0,0 +1,173 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package schema
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/persistence/sql"
+	"go.temporal.io/server/common/persistence/sql/sqlplugin"
+	sqliteplugin "go.temporal.io/server/common/persistence/sql/sqlplugin/sqlite"
+	"go.temporal.io/server/common/resolver"
+	"go.temporal.io/server/schema/sqlite"
+)
+
+func newTestConfig(t *testing.T) *config.SQL {
+	return &config.SQL{
+		PluginName:        sqliteplugin.PluginName,
+		DatabaseName:      filepath.Join(t.TempDir(), "test.db"),
+		ConnectAttributes: map[string]string{"mode": "rwc"},
+	}
+}
+
+func setVersion(t *testing.T, cfg *config.SQL, component, version string) {
+	db, err := sql.NewSQLAdminDB(sqlplugin.DbKindUnknown, cfg, resolver.NewNoopResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+	if err := db.UpdateSchemaVersion(component, version, version); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupNewDatabase(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := Setup(cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckVersion(cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Setup is idempotent
+	if err := Setup(cfg, false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupUnversionedDatabase(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := sqlite.SetupSchema(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Setup(cfg, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, err := Version(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions["temporal"] != sqlite.Version {
+		t.Errorf("expected temporal schema version %s, got %s", sqlite.Version, versions["temporal"])
+	}
+	if versions["temporal_visibility"] != sqlite.VisibilityVersion {
+		t.Errorf("expected visibility schema version %s, got %s", sqlite.VisibilityVersion, versions["temporal_visibility"])
+	}
+}
+
+func TestSetupOutdatedDatabase(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	setVersion(t, cfg, "temporal", "0.0")
+
+	var versionErr *VersionError
+	if err := Setup(cfg, false); !errors.As(err, &versionErr) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+	// No migration reaches the expected version, so the database must not be
+	// marked as current
+	if err := Setup(cfg, true); err == nil {
+		t.Fatal("expected migration error")
+	}
+	versions, err := Version(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if versions["temporal"] != "0.0" {
+		t.Errorf("expected temporal schema version to remain 0.0, got %s", versions["temporal"])
+	}
+}
+
+func TestSetupMigratesDatabase(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	setVersion(t, cfg, "temporal", "0.0")
+
+	defer func(orig []migration) { components[0].migrations = orig }(components[0].migrations)
+	components[0].migrations = []migration{{
+		version:     sqlite.Version,
+		description: "test migration",
+		statements:  []string{"CREATE TABLE migration_test (id INTEGER)"},
+	}}
+
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckVersion(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupNewerDatabase(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	setVersion(t, cfg, "temporal", "99.0")
+
+	var versionErr *VersionError
+	if err := Setup(cfg, true); !errors.As(err, &versionErr) {
+		t.Fatalf("expected version error, got %v", err)
+	}
+	if versionErr.Current != "99.0" {
+		t.Errorf("unexpected current version %q", versionErr.Current)
+	}
+}
+
+func TestCheckFileVersion(t *testing.T) {
+	cfg := newTestConfig(t)
+	if err := Setup(cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckFileVersion(cfg.DatabaseName); err != nil {
+		t.Fatal(err)
+	}
+	setVersion(t, cfg, "temporal_visibility", "99.0")
+
+	var versionErr *VersionError
+	if err := CheckFileVersion(cfg.DatabaseName); !errors.As(err, &versionErr) || !versionErr.Newer() {
+		t.Fatalf("expected newer version error, got %v", err)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		cmp  int
+	}{
+		{"0.1", "0.1", 0},
+		{"0.1", "0.2", -1},
+		{"0.10", "0.9", 1},
+		{"1", "1.0", 0},
+		{"1.0.1", "1.0", 1},
+	} {
+		cmp := compareVersions(tc.a, tc.b)
+		if (cmp < 0 && tc.cmp >= 0) || (cmp > 0 && tc.cmp <= 0) || (cmp == 0 && tc.cmp != 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, expected sign of %d", tc.a, tc.b, cmp, tc.cmp)
+		}
+	}
+}
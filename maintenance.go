@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"context"
+	"time"
+
+	"go.temporal.io/server/common/log/tag"
+
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// maintain returns free pages of the database files to the file system and
+// checkpoints their WAL every interval until ctx is done.
+func (s *Server) maintain(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, store := range fileDataStores(s.serverConfig) {
+				if err := litedb.IncrementalVacuum(store); err != nil {
+					s.config.Logger.Warn("Database maintenance failed", tag.NewStringTag("database", store.DatabaseName), tag.Error(err))
+				}
+			}
+		}
+	}
+}
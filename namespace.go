@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"fmt"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/schema/sqlite"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// NamespaceSpec describes a namespace registered on start by WithNamespaceConfigs.
+type NamespaceSpec = liteconfig.NamespaceSpec
+
+// validateNamespaceSpecs returns an error describing the first invalid spec.
+func validateNamespaceSpecs(specs []NamespaceSpec, archivalEnabled bool) error {
+	for _, spec := range specs {
+		if spec.Name == "" {
+			return fmt.Errorf("namespace name is required")
+		}
+		if spec.Retention < 0 {
+			return fmt.Errorf("namespace %q: invalid retention period: %s", spec.Name, spec.Retention)
+		}
+		for _, state := range []enums.ArchivalState{spec.HistoryArchival, spec.VisibilityArchival} {
+			if state == enums.ARCHIVAL_STATE_ENABLED && !archivalEnabled {
+				return fmt.Errorf("namespace %q: archival cannot be enabled unless the server is started with archival", spec.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// registerNamespaces creates the namespaces requested with WithNamespaces and
+// WithNamespaceConfigs. Namespaces requested by name only are left alone when they
+// already exist, while existing namespaces with a spec are updated to match it.
+func registerNamespaces(c *liteconfig.Config, cfg *config.Config) error {
+	sqlConfig := cfg.Persistence.DataStores[liteconfig.PersistenceStoreName].SQL
+	clusterName := cfg.ClusterMetadata.CurrentClusterName
+
+	var namespaces []*sqlite.NamespaceConfig
+	for _, ns := range c.Namespaces {
+		namespaces = append(namespaces, newNamespaceConfig(c, cfg, ns))
+	}
+	if err := sqlite.CreateNamespaces(sqlConfig, namespaces...); err != nil {
+		return err
+	}
+
+	for _, spec := range c.NamespaceSpecs {
+		detail, ok, err := litedb.GetNamespace(sqlConfig, clusterName, spec.Name)
+		if err != nil {
+			return fmt.Errorf("namespace %q: %w", spec.Name, err)
+		}
+		if !ok {
+			detail = newNamespaceConfig(c, cfg, spec.Name).Detail
+		}
+
+		litedb.ApplyNamespaceSpec(detail, spec, cfg.NamespaceDefaults.Archival)
+
+		if !ok {
+			err = litedb.CreateNamespace(sqlConfig, clusterName, detail)
+		} else {
+			detail.ConfigVersion++
+			err = litedb.UpdateNamespace(sqlConfig, clusterName, detail)
+		}
+		if err != nil {
+			return fmt.Errorf("namespace %q: %w", spec.Name, err)
+		}
+	}
+	return nil
+}
+
+// newNamespaceConfig returns the settings of a new namespace, with archival enabled
+// when the server is started with WithArchival.
+func newNamespaceConfig(c *liteconfig.Config, cfg *config.Config, name string) *sqlite.NamespaceConfig {
+	nsConfig := sqlite.NewNamespaceConfig(cfg.ClusterMetadata.CurrentClusterName, name, false)
+	if c.ArchivalDir != "" {
+		archival := cfg.NamespaceDefaults.Archival
+		nsConfig.Detail.Config.HistoryArchivalState = enums.ARCHIVAL_STATE_ENABLED
+		nsConfig.Detail.Config.HistoryArchivalUri = archival.History.URI
+		nsConfig.Detail.Config.VisibilityArchivalState = enums.ARCHIVAL_STATE_ENABLED
+		nsConfig.Detail.Config.VisibilityArchivalUri = archival.Visibility.URI
+	}
+	return nsConfig
+}
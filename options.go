@@ -5,6 +5,9 @@
 package temporalite
 
 import (
+	"time"
+
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/server/common/config"
 	"go.temporal.io/server/common/dynamicconfig"
 	"go.temporal.io/server/common/log"
@@ -28,6 +31,38 @@ func WithDatabaseFilePath(filepath string) ServerOption {
 	})
 }
 
+// WithVisibilityDatabaseFilePath stores visibility records, which back workflow
+// listing and search, in a separate file at the specified path so that visibility
+// queries do not contend with workflow updates.
+//
+// When unspecified, visibility records are stored with the rest of the state.
+func WithVisibilityDatabaseFilePath(filepath string) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.VisibilityEphemeral = false
+		cfg.VisibilityDatabaseFilePath = filepath
+	})
+}
+
+// WithVisibilityPersistenceDisabled stores visibility records in memory, even when
+// the rest of the state is persisted to a file. Workflows can no longer be listed
+// after a restart.
+func WithVisibilityPersistenceDisabled() ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.VisibilityEphemeral = true
+		cfg.VisibilityDatabaseFilePath = ""
+	})
+}
+
+// WithAutoMigrateDisabled prevents schema migrations from being applied to an existing
+// database file. NewServer returns an error if the file's schema is out of date.
+//
+// When unspecified, pending migrations are applied on startup.
+func WithAutoMigrateDisabled() ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.AutoMigrate = false
+	})
+}
+
 // WithPersistenceDisabled disables file persistence and uses the in-memory storage driver.
 // State will be reset on each process restart.
 func WithPersistenceDisabled() ServerOption {
@@ -36,6 +71,16 @@ func WithPersistenceDisabled() ServerOption {
 	})
 }
 
+// WithInitialSnapshot seeds the in-memory database with a snapshot taken by
+// Server.Snapshot, including any workflows that were running at the time.
+//
+// It requires WithPersistenceDisabled.
+func WithInitialSnapshot(snapshot []byte) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.InitialSnapshot = snapshot
+	})
+}
+
 // WithUI enables the Temporal web interface.
 //
 // When unspecified, Temporal will run in headless mode.
@@ -49,6 +94,16 @@ func WithUI(server liteconfig.UIServer) ServerOption {
 	})
 }
 
+// WithUIFailurePolicy determines how the server reacts when the UI server fails to start,
+// for example because its port is already in use.
+//
+// When unspecified, UIFailFast is used and Start returns the error.
+func WithUIFailurePolicy(policy UIFailurePolicy) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.UIFailurePolicy = policy
+	})
+}
+
 // WithFrontendPort sets the listening port for the temporal-frontend GRPC service.
 //
 // When unspecified, the default port number of 7233 is used.
@@ -84,6 +139,42 @@ func WithDynamicPorts() ServerOption {
 	})
 }
 
+// WithHistoryShards sets the number of history shards, which determines how many
+// workflow histories can be updated concurrently.
+//
+// The number of shards cannot be changed once a database has been used: NewServer
+// returns an error if it differs from the number the database was created with.
+// When unspecified, existing databases keep their number of shards and new ones
+// are created with a single shard.
+func WithHistoryShards(n int32) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.HistoryShards = n
+	})
+}
+
+// WithArchival enables history and visibility archival to the local filesystem,
+// under the history and visibility subdirectories of dir.
+//
+// Archival is enabled by default for namespaces registered through the API, and
+// for namespaces registered with WithNamespaces when they do not exist yet.
+func WithArchival(dir string) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.ArchivalDir = dir
+	})
+}
+
+// WithMaintenance returns the space freed in the database files to the file system
+// and checkpoints the SQLite WAL at the given interval while the server is running,
+// so that the files shrink once retention deletes closed workflows.
+//
+// Existing database files are switched to incremental auto-vacuum on startup,
+// which rebuilds them once. It has no effect when persistence is disabled.
+func WithMaintenance(interval time.Duration) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.MaintenanceInterval = interval
+	})
+}
+
 // WithNamespaces registers each namespace on Temporal start.
 func WithNamespaces(namespaces ...string) ServerOption {
 	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
@@ -91,6 +182,31 @@ func WithNamespaces(namespaces ...string) ServerOption {
 	})
 }
 
+// WithNamespaceConfigs registers each namespace on Temporal start with the given settings.
+//
+// Unlike namespaces registered with WithNamespaces, namespaces that already exist
+// are updated to match their spec.
+func WithNamespaceConfigs(specs []NamespaceSpec) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		cfg.NamespaceSpecs = append(cfg.NamespaceSpecs, specs...)
+	})
+}
+
+// WithSearchAttributes registers custom search attributes before the server starts
+// serving, so that workflows can use them right away.
+//
+// Search attributes that are already registered must have the same type.
+func WithSearchAttributes(attributes map[string]enums.IndexedValueType) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		if cfg.SearchAttributes == nil {
+			cfg.SearchAttributes = make(map[string]enums.IndexedValueType)
+		}
+		for k, v := range attributes {
+			cfg.SearchAttributes[k] = v
+		}
+	})
+}
+
 // WithSQLitePragmas applies pragma statements to SQLite on Temporal start.
 func WithSQLitePragmas(pragmas map[string]string) ServerOption {
 	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
@@ -121,7 +237,8 @@ func WithBaseConfig(base *config.Config) ServerOption {
 }
 
 // WithDynamicConfigValue sets the given dynamic config key with the given set
-// of values. This will overwrite the key if already set.
+// of values. This will overwrite the key if already set, including in the file
+// passed to WithDynamicConfigFile.
 func WithDynamicConfigValue(key dynamicconfig.Key, value []dynamicconfig.ConstrainedValue) ServerOption {
 	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
 		if cfg.DynamicConfig == nil {
@@ -131,6 +248,24 @@ func WithDynamicConfigValue(key dynamicconfig.Key, value []dynamicconfig.Constra
 	})
 }
 
+// WithDynamicConfigFile loads dynamic config values from the file at the specified
+// path, which is checked for changes at the given interval. The interval must be at
+// least five seconds; when zero, it defaults to ten seconds.
+//
+// Values set with WithDynamicConfigValue take precedence over the values of the
+// file. This overrides the dynamicConfigClient setting of WithBaseConfig.
+func WithDynamicConfigFile(path string, pollInterval time.Duration) ServerOption {
+	return newApplyFuncContainer(func(cfg *liteconfig.Config) {
+		if pollInterval == 0 {
+			pollInterval = defaultDynamicConfigPollInterval
+		}
+		cfg.DynamicConfigFile = &dynamicconfig.FileBasedClientConfig{
+			Filepath:     path,
+			PollInterval: pollInterval,
+		}
+	})
+}
+
 // WithSearchAttributeCacheDisabled disables search attribute caching. This
 // delegates to WithDynamicConfigValue.
 func WithSearchAttributeCacheDisabled() ServerOption {
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	enumspb "go.temporal.io/api/enums/v1"
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/server/api/adminservice/v1"
+	"go.temporal.io/server/common/metrics"
+	"go.temporal.io/server/common/primitives"
+	"go.temporal.io/server/common/rpc/encryption"
+	"go.temporal.io/server/service/worker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+)
+
+const (
+	readinessPollInterval = 100 * time.Millisecond
+	readinessProbeTimeout = 2 * time.Second
+	// Service name registered by the frontend with the gRPC health server.
+	frontendHealthServiceName = "temporal.api.workflowservice.v1.WorkflowService"
+)
+
+// readiness tracks whether the server has become ready to serve requests.
+type readiness struct {
+	once   sync.Once
+	ready  chan struct{}
+	err    error
+	mu     sync.Mutex
+	status error
+}
+
+func newReadiness() *readiness {
+	return &readiness{ready: make(chan struct{})}
+}
+
+// done marks the server as ready, or as failed when err is not nil.
+// Only the first call has any effect.
+func (r *readiness) done(err error) {
+	r.once.Do(func() {
+		r.err = err
+		close(r.ready)
+	})
+}
+
+func (r *readiness) setStatus(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = err
+}
+
+func (r *readiness) getStatus() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Ready returns a channel that is closed once the server is ready to serve
+// requests or has failed to start.
+//
+// Use WaitReady to find out whether startup succeeded.
+func (s *Server) Ready() <-chan struct{} {
+	return s.readiness.ready
+}
+
+// WaitReady blocks until the frontend, history, matching and worker services are
+// serving, the UI server (when enabled) is accepting connections, and every namespace
+// registered via WithNamespaces or WithNamespaceConfigs can be described.
+//
+// An error is returned if the server fails to start, is stopped before becoming ready,
+// or if ctx is done first. In the latter case the error describes which component
+// was still not ready.
+func (s *Server) WaitReady(ctx context.Context) error {
+	select {
+	case <-s.readiness.ready:
+		return s.readiness.err
+	case <-ctx.Done():
+		if status := s.readiness.getStatus(); status != nil {
+			return fmt.Errorf("server not ready: %w: %v", ctx.Err(), status)
+		}
+		return fmt.Errorf("server not ready: %w", ctx.Err())
+	}
+}
+
+// awaitReadiness probes the server until it is ready or ctx is done.
+func (s *Server) awaitReadiness(ctx context.Context) {
+	conn, err := s.dialFrontend()
+	if err != nil {
+		s.readiness.done(fmt.Errorf("unable to connect to frontend: %w", err))
+		return
+	}
+	defer func() { _ = conn.Close() }()
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+		err := s.probe(probeCtx, conn)
+		cancel()
+		if err == nil {
+			s.readiness.done(nil)
+			return
+		}
+		s.readiness.setStatus(err)
+
+		select {
+		case <-ctx.Done():
+			s.readiness.done(fmt.Errorf("server stopped before becoming ready: %v", err))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe returns an error describing the first component found not to be ready.
+func (s *Server) probe(ctx context.Context, conn *grpc.ClientConn) error {
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{
+		Service: frontendHealthServiceName,
+	})
+	if err != nil {
+		return fmt.Errorf("frontend health check failed: %w", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("frontend is not serving: %v", resp.Status)
+	}
+
+	cluster, err := adminservice.NewAdminServiceClient(conn).DescribeCluster(ctx, &adminservice.DescribeClusterRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to describe cluster: %w", err)
+	}
+	members := make(map[string]int32)
+	for _, ring := range cluster.GetMembershipInfo().GetRings() {
+		members[ring.GetRole()] = ring.GetMemberCount()
+	}
+	for _, role := range []string{primitives.HistoryService, primitives.MatchingService, primitives.WorkerService} {
+		if members[role] == 0 {
+			return fmt.Errorf("%s service has not joined the cluster", role)
+		}
+	}
+
+	workflowClient := workflowservice.NewWorkflowServiceClient(conn)
+	tq, err := workflowClient.DescribeTaskQueue(ctx, &workflowservice.DescribeTaskQueueRequest{
+		Namespace:     primitives.SystemLocalNamespace,
+		TaskQueue:     &taskqueuepb.TaskQueue{Name: worker.DefaultWorkerTaskQueue},
+		TaskQueueType: enumspb.TASK_QUEUE_TYPE_WORKFLOW,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to describe system task queue: %w", err)
+	}
+	if len(tq.GetPollers()) == 0 {
+		return fmt.Errorf("%s service is not polling its system task queue", primitives.WorkerService)
+	}
+
+	namespaces := append([]string(nil), s.config.Namespaces...)
+	for _, spec := range s.config.NamespaceSpecs {
+		namespaces = append(namespaces, spec.Name)
+	}
+	for _, ns := range namespaces {
+		if _, err := workflowClient.DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{
+			Namespace: ns,
+		}); err != nil {
+			return fmt.Errorf("namespace %q is not available: %w", ns, err)
+		}
+	}
+
+	if ui, ok := s.ui.(liteconfig.AddressableUIServer); ok {
+		var d net.Dialer
+		c, err := d.DialContext(ctx, "tcp", ui.Addr())
+		if err != nil {
+			return fmt.Errorf("ui server is not accepting connections: %w", err)
+		}
+		_ = c.Close()
+	}
+
+	return nil
+}
+
+// dialFrontend returns a connection to the frontend using the same TLS settings
+// the server uses to connect to itself.
+func (s *Server) dialFrontend() (*grpc.ClientConn, error) {
+	creds := insecure.NewCredentials()
+	if s.serverConfig.Global.TLS.Frontend.IsClientEnabled() {
+		provider, err := encryption.NewTLSConfigProviderFromConfig(s.serverConfig.Global.TLS, metrics.NoopMetricsHandler, s.config.Logger, nil)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig, err := provider.GetFrontendClientConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			return nil, errors.New("frontend client TLS is enabled but no client config is available")
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	return grpc.Dial(s.frontendHostPort, grpc.WithTransportCredentials(creds))
+}
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"fmt"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/searchattribute"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// customSearchAttributes returns the search attributes requested with WithSearchAttributes
+// and WithNamespaceConfigs, or an error if any of them is invalid.
+func customSearchAttributes(c *liteconfig.Config) (map[string]enums.IndexedValueType, error) {
+	attributes := make(map[string]enums.IndexedValueType)
+	add := func(name string, t enums.IndexedValueType) error {
+		if searchattribute.IsReserved(name) {
+			return fmt.Errorf("search attribute %q is reserved", name)
+		}
+		if _, ok := enums.IndexedValueType_name[int32(t)]; !ok || t == enums.INDEXED_VALUE_TYPE_UNSPECIFIED {
+			return fmt.Errorf("search attribute %q has invalid type %d", name, t)
+		}
+		if existing, ok := attributes[name]; ok && existing != t {
+			return fmt.Errorf("search attribute %q is declared with both types %s and %s", name, existing, t)
+		}
+		attributes[name] = t
+		return nil
+	}
+
+	for name, t := range c.SearchAttributes {
+		if err := add(name, t); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range c.NamespaceSpecs {
+		for name, t := range spec.SearchAttributes {
+			if err := add(name, t); err != nil {
+				return nil, fmt.Errorf("namespace %q: %w", spec.Name, err)
+			}
+		}
+	}
+	return attributes, nil
+}
+
+// registerSearchAttributes adds the requested search attributes before any service
+// starts, so that they can be used as soon as the server is ready. It must be called
+// once the cluster metadata has been initialized.
+func registerSearchAttributes(attributes map[string]enums.IndexedValueType, cfg *config.Config) error {
+	if len(attributes) == 0 {
+		return nil
+	}
+	sqlConfig := cfg.Persistence.DataStores[liteconfig.PersistenceStoreName].SQL
+	return litedb.AddSearchAttributes(sqlConfig, cfg.ClusterMetadata.CurrentClusterName, attributes)
+}
@@ -10,15 +10,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/server/common/authorization"
 	"go.temporal.io/server/common/config"
-	"go.temporal.io/server/schema/sqlite"
 	"go.temporal.io/server/temporal"
 
 	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+	"github.com/temporalio/temporalite/internal/schema"
 )
 
 // Server wraps temporal.Server.
@@ -27,6 +30,17 @@ type Server struct {
 	ui               liteconfig.UIServer
 	frontendHostPort string
 	config           *liteconfig.Config
+	serverConfig     *config.Config
+	readiness        *readiness
+	stopReadiness    context.CancelFunc
+	stopMaintenance  context.CancelFunc
+	requests         *requestTracker
+	// Values of the dynamic config file merged with WithDynamicConfigValue, if
+	// there is a file
+	dynamicConfig     *layeredDynamicConfig
+	stopDynamicConfig func()
+	// Release the locks taken on the database files by Start
+	databaseLocks []func() error
 }
 
 type ServerOption interface {
@@ -35,43 +49,51 @@ type ServerOption interface {
 
 // NewServer returns a new instance of Server.
 func NewServer(opts ...ServerOption) (*Server, error) {
-	c, err := liteconfig.NewDefaultConfig()
+	c, cfg, searchAttributes, err := newConfig(opts)
 	if err != nil {
 		return nil, err
 	}
-	for _, opt := range opts {
-		opt.apply(c)
-	}
+	sqlConfig := cfg.Persistence.DataStores[liteconfig.PersistenceStoreName].SQL
 
-	for pragma := range c.SQLitePragmas {
-		if _, ok := liteconfig.SupportedPragmas[strings.ToLower(pragma)]; !ok {
-			return nil, fmt.Errorf("ERROR: unsupported pragma %q, %v allowed", pragma, liteconfig.GetAllowedPragmas())
+	if !c.Ephemeral {
+		if err := setupDatabaseFile(c.DatabaseFilePath, sqlConfig, c.AutoMigrate); err != nil {
+			return nil, err
+		}
+	} else if len(c.InitialSnapshot) > 0 {
+		if err := loadSnapshot(sqlConfig, c.InitialSnapshot); err != nil {
+			return nil, fmt.Errorf("error loading initial snapshot: %w", err)
 		}
 	}
 
-	cfg := liteconfig.Convert(c)
-	sqlConfig := cfg.Persistence.DataStores[liteconfig.PersistenceStoreName].SQL
+	if c.VisibilityDatabaseFilePath != "" && !c.VisibilityEphemeral {
+		visibilityConfig := cfg.Persistence.DataStores[liteconfig.VisibilityStoreName].SQL
+		if err := setupDatabaseFile(c.VisibilityDatabaseFilePath, visibilityConfig, c.AutoMigrate); err != nil {
+			return nil, fmt.Errorf("visibility database: %w", err)
+		}
+	}
 
-	if !c.Ephemeral {
-		// Apply migrations if file does not already exist
-		if _, err := os.Stat(c.DatabaseFilePath); os.IsNotExist(err) {
-			// Check if any of the parent dirs are missing
-			dir := filepath.Dir(c.DatabaseFilePath)
-			if _, err := os.Stat(dir); err != nil {
-				return nil, fmt.Errorf("error setting up schema: %w", err)
+	if c.MaintenanceInterval > 0 {
+		for _, store := range fileDataStores(cfg) {
+			if err := litedb.EnableIncrementalVacuum(store); err != nil {
+				return nil, fmt.Errorf("error setting up maintenance of %s: %w", store.DatabaseName, err)
 			}
+		}
+	}
 
-			if err := sqlite.SetupSchema(sqlConfig); err != nil {
-				return nil, fmt.Errorf("error setting up schema: %w", err)
-			}
+	// The cluster records its number of history shards on first start
+	shards, ok, err := litedb.HistoryShardCount(sqlConfig, cfg.ClusterMetadata.CurrentClusterName)
+	if err != nil {
+		return nil, fmt.Errorf("error reading number of history shards: %w", err)
+	}
+	if ok {
+		if c.HistoryShards != 0 && c.HistoryShards != shards {
+			return nil, fmt.Errorf("database was created with %d history shards, which cannot be changed to %d", shards, c.HistoryShards)
 		}
+		cfg.Persistence.NumHistoryShards = shards
 	}
+
 	// Pre-create namespaces
-	var namespaces []*sqlite.NamespaceConfig
-	for _, ns := range c.Namespaces {
-		namespaces = append(namespaces, sqlite.NewNamespaceConfig(cfg.ClusterMetadata.CurrentClusterName, ns, false))
-	}
-	if err := sqlite.CreateNamespaces(sqlConfig, namespaces...); err != nil {
+	if err := registerNamespaces(c, cfg); err != nil {
 		return nil, fmt.Errorf("error creating namespaces: %w", err)
 	}
 
@@ -85,6 +107,7 @@ func NewServer(opts ...ServerOption) (*Server, error) {
 		return nil, fmt.Errorf("unable to instantiate claim mapper: %w", err)
 	}
 
+	requests := newRequestTracker()
 	serverOpts := []temporal.ServerOption{
 		temporal.WithConfig(cfg),
 		temporal.ForServices(temporal.Services),
@@ -93,14 +116,27 @@ func NewServer(opts ...ServerOption) (*Server, error) {
 		temporal.WithClaimMapper(func(cfg *config.Config) authorization.ClaimMapper {
 			return claimMapper
 		}),
+		temporal.WithChainedFrontendGrpcInterceptors(requests.intercept),
 	}
 
-	if len(c.DynamicConfig) > 0 {
-		// To prevent having to code fall-through semantics right now, we currently
-		// eagerly fail if dynamic config is being configured in two ways
-		if cfg.DynamicConfigClient != nil {
-			return nil, fmt.Errorf("unable to have file-based dynamic config and individual dynamic config values")
+	// Values set with WithDynamicConfigValue take precedence over the dynamic
+	// config file, which is reloaded when it changes
+	var (
+		dynamicConfig     *layeredDynamicConfig
+		stopDynamicConfig = func() {}
+	)
+	if cfg.DynamicConfigClient != nil {
+		done := make(chan interface{})
+		var once sync.Once
+		stopDynamicConfig = func() { once.Do(func() { close(done) }) }
+
+		dynamicConfig, err = newLayeredDynamicConfig(cfg.DynamicConfigClient, c.DynamicConfig, c.Logger, done)
+		if err != nil {
+			stopDynamicConfig()
+			return nil, fmt.Errorf("unable to load dynamic config file: %w", err)
 		}
+		serverOpts = append(serverOpts, temporal.WithDynamicConfigClient(dynamicConfig))
+	} else if len(c.DynamicConfig) > 0 {
 		serverOpts = append(serverOpts, temporal.WithDynamicConfigClient(c.DynamicConfig))
 	}
 
@@ -110,33 +146,141 @@ func NewServer(opts ...ServerOption) (*Server, error) {
 
 	srv, err := temporal.NewServer(serverOpts...)
 	if err != nil {
+		stopDynamicConfig()
 		return nil, fmt.Errorf("unable to instantiate server: %w", err)
 	}
 
+	// Search attributes are stored in the cluster metadata, which is initialized
+	// when instantiating the server
+	if err := registerSearchAttributes(searchAttributes, cfg); err != nil {
+		stopDynamicConfig()
+		return nil, fmt.Errorf("error registering search attributes: %w", err)
+	}
+
 	s := &Server{
-		internal:         srv,
-		ui:               c.UIServer,
-		frontendHostPort: cfg.PublicClient.HostPort,
-		config:           c,
+		internal:          srv,
+		ui:                c.UIServer,
+		frontendHostPort:  cfg.PublicClient.HostPort,
+		config:            c,
+		serverConfig:      cfg,
+		readiness:         newReadiness(),
+		requests:          requests,
+		dynamicConfig:     dynamicConfig,
+		stopDynamicConfig: stopDynamicConfig,
 	}
 
 	return s, nil
 }
 
+// newConfig applies opts to the default config, validates the result and converts
+// it to the config of the Temporal server. It does not access the database files.
+func newConfig(opts []ServerOption) (*liteconfig.Config, *config.Config, map[string]enums.IndexedValueType, error) {
+	c, err := liteconfig.NewDefaultConfig()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+
+	for pragma, value := range c.SQLitePragmas {
+		if err := liteconfig.ValidatePragma(pragma, value); err != nil {
+			return nil, nil, nil, fmt.Errorf("ERROR: %w", err)
+		}
+		// Maintenance relies on incremental auto-vacuum
+		if c.MaintenanceInterval > 0 && strings.EqualFold(pragma, "auto_vacuum") && !strings.EqualFold(value, "incremental") && value != "2" {
+			return nil, nil, nil, fmt.Errorf("the auto_vacuum pragma must be incremental when maintenance is enabled")
+		}
+	}
+
+	if c.HistoryShards < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid number of history shards: %d", c.HistoryShards)
+	}
+	if c.MaintenanceInterval < 0 {
+		return nil, nil, nil, fmt.Errorf("invalid maintenance interval: %s", c.MaintenanceInterval)
+	}
+	if len(c.InitialSnapshot) > 0 && !c.Ephemeral {
+		return nil, nil, nil, fmt.Errorf("an initial snapshot can only be loaded with persistence disabled")
+	}
+
+	if err := validateNamespaceSpecs(c.NamespaceSpecs, c.ArchivalDir != ""); err != nil {
+		return nil, nil, nil, err
+	}
+	searchAttributes, err := customSearchAttributes(c)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if c.VisibilityDatabaseFilePath != "" && c.VisibilityDatabaseFilePath == c.DatabaseFilePath {
+		return nil, nil, nil, fmt.Errorf("the visibility database file must be different from the database file")
+	}
+
+	if c.ArchivalDir != "" {
+		// The filestore archiver only accepts absolute paths
+		dir, err := filepath.Abs(c.ArchivalDir)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid archival directory: %w", err)
+		}
+		c.ArchivalDir = dir
+	}
+
+	return c, liteconfig.Convert(c), searchAttributes, nil
+}
+
 // Start temporal server.
+//
+// The UI server, when enabled, is started first. If it fails to start, the
+// policy set by WithUIFailurePolicy applies.
+//
+// Use Ready or WaitReady to be notified once the server is accepting requests.
 func (s *Server) Start() error {
-	go func() {
-		if err := s.ui.Start(); err != nil {
-			panic(err)
+	if err := s.lockDatabases(); err != nil {
+		s.readiness.done(err)
+		return err
+	}
+
+	if err := s.startUI(); err != nil {
+		s.readiness.done(err)
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.stopReadiness = cancel
+	go s.awaitReadiness(ctx)
+
+	// The internal server blocks until it is stopped when started with
+	// temporal.InterruptOn, so maintenance must be running by then
+	if s.config.MaintenanceInterval > 0 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.stopMaintenance = cancel
+		go s.maintain(ctx, s.config.MaintenanceInterval)
+	}
+
+	if err := s.internal.Start(); err != nil {
+		cancel()
+		if s.stopMaintenance != nil {
+			s.stopMaintenance()
 		}
-	}()
-	return s.internal.Start()
+		s.readiness.done(fmt.Errorf("unable to start server: %w", err))
+		return err
+	}
+	return nil
 }
 
-// Stop the server.
+// Stop the server immediately.
+//
+// Use Shutdown to let in-flight requests complete first.
 func (s *Server) Stop() {
+	if s.stopReadiness != nil {
+		s.stopReadiness()
+	}
+	if s.stopMaintenance != nil {
+		s.stopMaintenance()
+	}
 	s.ui.Stop()
 	s.internal.Stop()
+	s.stopDynamicConfig()
+	s.unlockDatabases()
 }
 
 // NewClient initializes a client ready to communicate with the Temporal
@@ -163,6 +307,25 @@ func (s *Server) FrontendHostPort() string {
 	return s.frontendHostPort
 }
 
+// setupDatabaseFile creates the schema in a new database file, or upgrades it in an existing one.
+//
+// Files holding only visibility records get the full schema too, since the schema
+// version is tracked for the schema as a whole.
+func setupDatabaseFile(path string, sqlConfig *config.SQL, autoMigrate bool) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		// Check if any of the parent dirs are missing
+		dir := filepath.Dir(path)
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("error setting up schema: %w", err)
+		}
+	}
+
+	if err := schema.Setup(sqlConfig, autoMigrate); err != nil {
+		return fmt.Errorf("error setting up schema: %w", err)
+	}
+	return nil
+}
+
 func timeoutFromContext(ctx context.Context, defaultTimeout time.Duration) time.Duration {
 	if deadline, ok := ctx.Deadline(); ok {
 		return deadline.Sub(time.Now())
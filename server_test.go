@@ -0,0 +1,825 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/config"
+	"go.temporal.io/server/common/dynamicconfig"
+	"go.temporal.io/server/common/log"
+	sqliteplugin "go.temporal.io/server/common/persistence/sql/sqlplugin/sqlite"
+
+	"github.com/temporalio/temporalite"
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+func newTestServer(t *testing.T, opts ...temporalite.ServerOption) *temporalite.Server {
+	// Options passed by the caller take precedence
+	opts = append([]temporalite.ServerOption{
+		temporalite.WithPersistenceDisabled(),
+		temporalite.WithDynamicPorts(),
+		temporalite.WithLogger(log.NewNoopLogger()),
+	}, opts...)
+	s, err := temporalite.NewServer(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestWaitReady(t *testing.T) {
+	s := newTestServer(t, temporalite.WithNamespaces("foo", "bar"))
+	defer s.Stop()
+
+	select {
+	case <-s.Ready():
+		t.Fatal("server ready before being started")
+	default:
+	}
+
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.NewClient(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.CheckHealth(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitReadyContextDone(t *testing.T) {
+	s := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.WaitReady(ctx); err == nil {
+		t.Fatal("expected error waiting for a server that was never started")
+	}
+}
+
+type failingUIServer struct {
+	rebindPort int
+}
+
+func (*failingUIServer) Start() error {
+	return errors.New("address already in use")
+}
+
+func (*failingUIServer) Stop() {}
+
+func (f *failingUIServer) WithPort(port int) (liteconfig.UIServer, error) {
+	f.rebindPort = port
+	return liteconfig.NoopUIServer{}, nil
+}
+
+func TestUIFailurePolicy(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	t.Run("fail fast", func(t *testing.T) {
+		s := newTestServer(t, temporalite.WithUI(&failingUIServer{}))
+		if err := s.Start(); err == nil {
+			s.Stop()
+			t.Fatal("expected error starting server")
+		}
+		if err := s.WaitReady(ctx); err == nil {
+			t.Fatal("expected readiness error")
+		}
+	})
+
+	t.Run("log and continue", func(t *testing.T) {
+		s := newTestServer(t,
+			temporalite.WithUI(&failingUIServer{}),
+			temporalite.WithUIFailurePolicy(temporalite.UILogAndContinue),
+		)
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+		if err := s.WaitReady(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("retry on another port", func(t *testing.T) {
+		ui := &failingUIServer{}
+		s := newTestServer(t,
+			temporalite.WithUI(ui),
+			temporalite.WithUIFailurePolicy(temporalite.UIRetryOnAnotherPort),
+		)
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		defer s.Stop()
+		if ui.rebindPort == 0 {
+			t.Fatal("UI server was not restarted on another port")
+		}
+		if err := s.WaitReady(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// listeningUIServer is an addressable UI server that fails to start when its
+// address is already in use.
+type listeningUIServer struct {
+	addr     string
+	listener net.Listener
+}
+
+func (u *listeningUIServer) Start() error {
+	l, err := net.Listen("tcp", u.addr)
+	if err != nil {
+		return err
+	}
+	u.listener = l
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return nil
+		}
+		_ = c.Close()
+	}
+}
+
+func (u *listeningUIServer) Stop() {
+	if u.listener != nil {
+		_ = u.listener.Close()
+	}
+}
+
+func (u *listeningUIServer) Addr() string {
+	return u.addr
+}
+
+func TestUIOccupiedPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(t, temporalite.WithUI(&listeningUIServer{addr: l.Addr().String()}))
+	if err := s.Start(); err == nil {
+		s.Stop()
+		t.Fatal("expected error starting UI server on an occupied port")
+	}
+
+	// The UI server starts once the port is released
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s = newTestServer(t, temporalite.WithUI(&listeningUIServer{addr: l.Addr().String()}))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer(t, temporalite.WithNamespaces("default"))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.NewClient(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Leave a long poll in flight
+	polling := make(chan struct{})
+	go func() {
+		close(polling)
+		_, _ = c.WorkflowService().PollActivityTaskQueue(ctx, &workflowservice.PollActivityTaskQueueRequest{
+			Namespace: "default",
+			TaskQueue: &taskqueue.TaskQueue{Name: "shutdown"},
+		})
+	}()
+	<-polling
+	time.Sleep(100 * time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancel()
+	err = s.Shutdown(shutdownCtx)
+	if err == nil {
+		t.Fatal("expected in-flight long poll to be reported")
+	}
+	if !strings.Contains(err.Error(), "PollActivityTaskQueue (1)") {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestShutdownPersistent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer(t,
+		temporalite.WithDatabaseFilePath(filepath.Join(t.TempDir(), "temporalite.db")),
+		temporalite.WithSQLitePragmas(map[string]string{"journal_mode": "wal"}),
+	)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer(t, temporalite.WithNamespaces("foo"))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	backupPath := filepath.Join(t.TempDir(), "backup.db")
+	f, err := os.Create(backupPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Backup(ctx, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Namespaces from the original server are available without being pre-created
+	restored := newTestServer(t, temporalite.WithDatabaseFilePath(backupPath))
+	if err := restored.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Stop()
+	if err := restored.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c, err := restored.NewClient(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.WorkflowService().DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{Namespace: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer(t, temporalite.WithNamespaces("foo"))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.NewClient(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// No worker polls the task queue, so the workflow keeps running
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{ID: "fixture", TaskQueue: "snapshot"}, "Fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, err := s.Snapshot(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seeded := newTestServer(t, temporalite.WithInitialSnapshot(snapshot))
+	if err := seeded.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer seeded.Stop()
+	if err := seeded.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := seeded.NewClient(ctx, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sc.Close()
+	resp, err := sc.DescribeWorkflowExecution(ctx, "fixture", run.GetRunID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := resp.GetWorkflowExecutionInfo().GetStatus(); status != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+		t.Fatalf("expected running workflow, got %s", status)
+	}
+}
+
+func TestInitialSnapshotRequiresEphemeral(t *testing.T) {
+	_, err := temporalite.NewServer(
+		temporalite.WithDatabaseFilePath(filepath.Join(t.TempDir(), "temporalite.db")),
+		temporalite.WithInitialSnapshot([]byte("snapshot")),
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestSQLitePragmas(t *testing.T) {
+	for _, tc := range []struct {
+		pragma, value string
+		valid         bool
+	}{
+		{"journal_mode", "WAL", true},
+		{"journal_mode", "fast", false},
+		{"cache_size", "-64000", true},
+		{"cache_size", "large", false},
+		{"busy_timeout", "5000", true},
+		{"busy_timeout", "-1", false},
+		{"mmap_size", "268435456", true},
+		{"temp_store", "memory", true},
+		{"temp_store", "3", false},
+		{"wal_autocheckpoint", "1000", true},
+		{"foreign_keys", "on", true},
+		{"page_size", "1000", false},
+		{"recursive_triggers", "on", false},
+	} {
+		_, err := temporalite.NewServer(
+			temporalite.WithPersistenceDisabled(),
+			temporalite.WithDynamicPorts(),
+			temporalite.WithLogger(log.NewNoopLogger()),
+			temporalite.WithSQLitePragmas(map[string]string{tc.pragma: tc.value}),
+		)
+		if tc.valid && err != nil {
+			t.Errorf("%s=%s: unexpected error: %s", tc.pragma, tc.value, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%s=%s: expected error", tc.pragma, tc.value)
+		}
+	}
+}
+
+func TestHistoryShards(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+	s := newTestServer(t, temporalite.WithDatabaseFilePath(dbPath), temporalite.WithHistoryShards(4))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	if _, err := temporalite.NewServer(
+		temporalite.WithDatabaseFilePath(dbPath),
+		temporalite.WithDynamicPorts(),
+		temporalite.WithLogger(log.NewNoopLogger()),
+		temporalite.WithHistoryShards(2),
+	); err == nil || !strings.Contains(err.Error(), "created with 4 history shards") {
+		t.Fatalf("expected shard count mismatch error, got %v", err)
+	}
+
+	newTestServer(t, temporalite.WithDatabaseFilePath(dbPath), temporalite.WithHistoryShards(4))
+	// The existing number of shards is used when unspecified
+	newTestServer(t, temporalite.WithDatabaseFilePath(dbPath))
+}
+
+func TestVisibilityDatabase(t *testing.T) {
+	for name, opt := range map[string]temporalite.ServerOption{
+		"file":      temporalite.WithVisibilityDatabaseFilePath(filepath.Join(t.TempDir(), "visibility.db")),
+		"in-memory": temporalite.WithVisibilityPersistenceDisabled(),
+	} {
+		opt := opt
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			s := newTestServer(t,
+				temporalite.WithDatabaseFilePath(filepath.Join(t.TempDir(), "temporalite.db")),
+				temporalite.WithNamespaces("default"),
+				opt,
+			)
+			if err := s.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer s.Stop()
+			if err := s.WaitReady(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := s.NewClient(ctx, "default")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if _, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{ID: "listed", TaskQueue: "visibility"}, "Listed"); err != nil {
+				t.Fatal(err)
+			}
+
+			for {
+				resp, err := c.ListOpenWorkflow(ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{Namespace: "default"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(resp.GetExecutions()) == 1 {
+					return
+				}
+				select {
+				case <-ctx.Done():
+					t.Fatal("workflow not listed")
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+		})
+	}
+}
+
+func TestArchival(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dir := t.TempDir()
+	s := newTestServer(t, temporalite.WithArchival(dir), temporalite.WithNamespaces("default"))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.NewClient(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	resp, err := c.WorkflowService().DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{Namespace: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := resp.GetConfig().GetHistoryArchivalState(); state != enums.ARCHIVAL_STATE_ENABLED {
+		t.Fatalf("expected history archival to be enabled, got %s", state)
+	}
+
+	run, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{ID: "archived", TaskQueue: "archival"}, "Archived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.TerminateWorkflow(ctx, "archived", run.GetRunID(), "archive"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Visibility records are archived once the workflow is closed, while histories
+	// are only archived when the retention period expires
+	for {
+		entries, err := os.ReadDir(filepath.Join(dir, "visibility"))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		if len(entries) > 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatal("visibility record not archived")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func TestNamespaceConfigs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+	spec := temporalite.NamespaceSpec{
+		Name:             "orders",
+		Description:      "Order processing",
+		OwnerEmail:       "orders@example.com",
+		Retention:        72 * time.Hour,
+		SearchAttributes: map[string]enums.IndexedValueType{"CustomerId": enums.INDEXED_VALUE_TYPE_KEYWORD},
+	}
+	// Namespaces are registered when the server is instantiated
+	newTestServer(t,
+		temporalite.WithDatabaseFilePath(dbPath),
+		temporalite.WithNamespaceConfigs([]temporalite.NamespaceSpec{spec}),
+	)
+
+	// Existing namespaces are reconciled, keeping the settings left unset
+	s := newTestServer(t,
+		temporalite.WithDatabaseFilePath(dbPath),
+		temporalite.WithNamespaceConfigs([]temporalite.NamespaceSpec{{Name: "orders", Description: "Orders"}}),
+	)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.NewClient(ctx, "orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	resp, err := c.WorkflowService().DescribeNamespace(ctx, &workflowservice.DescribeNamespaceRequest{Namespace: "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.GetNamespaceInfo().GetDescription(); got != "Orders" {
+		t.Errorf("expected updated description, got %q", got)
+	}
+	if got := resp.GetNamespaceInfo().GetOwnerEmail(); got != spec.OwnerEmail {
+		t.Errorf("expected owner email %q, got %q", spec.OwnerEmail, got)
+	}
+	if got := *resp.GetConfig().GetWorkflowExecutionRetentionTtl(); got != spec.Retention {
+		t.Errorf("expected retention %s, got %s", spec.Retention, got)
+	}
+
+	attrs, err := c.OperatorService().ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := attrs.GetCustomAttributes()["CustomerId"]; got != enums.INDEXED_VALUE_TYPE_KEYWORD {
+		t.Errorf("expected CustomerId search attribute of type keyword, got %s", got)
+	}
+}
+
+func TestNamespaceConfigsValidation(t *testing.T) {
+	for name, spec := range map[string]temporalite.NamespaceSpec{
+		"missing name":         {},
+		"archival not enabled": {Name: "foo", HistoryArchival: enums.ARCHIVAL_STATE_ENABLED},
+		"reserved attribute":   {Name: "foo", SearchAttributes: map[string]enums.IndexedValueType{"WorkflowId": enums.INDEXED_VALUE_TYPE_KEYWORD}},
+		"unspecified type":     {Name: "foo", SearchAttributes: map[string]enums.IndexedValueType{"CustomerId": enums.INDEXED_VALUE_TYPE_UNSPECIFIED}},
+	} {
+		spec := spec
+		t.Run(name, func(t *testing.T) {
+			if _, err := temporalite.NewServer(temporalite.WithPersistenceDisabled(), temporalite.WithNamespaceConfigs([]temporalite.NamespaceSpec{spec})); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestSearchAttributes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s := newTestServer(t,
+		temporalite.WithNamespaces("default"),
+		temporalite.WithSearchAttributes(map[string]enums.IndexedValueType{"CustomerId": enums.INDEXED_VALUE_TYPE_KEYWORD}),
+	)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := s.NewClient(ctx, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	// The search attribute is usable without waiting for caches to be refreshed
+	if _, err := c.ExecuteWorkflow(ctx, client.StartWorkflowOptions{
+		ID:               "searchable",
+		TaskQueue:        "search-attributes",
+		SearchAttributes: map[string]interface{}{"CustomerId": "c-123"},
+	}, "Searchable"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearchAttributesConflict(t *testing.T) {
+	_, err := temporalite.NewServer(
+		temporalite.WithPersistenceDisabled(),
+		temporalite.WithSearchAttributes(map[string]enums.IndexedValueType{"CustomerId": enums.INDEXED_VALUE_TYPE_KEYWORD}),
+		temporalite.WithNamespaceConfigs([]temporalite.NamespaceSpec{{
+			Name:             "orders",
+			SearchAttributes: map[string]enums.IndexedValueType{"CustomerId": enums.INDEXED_VALUE_TYPE_INT},
+		}}),
+	)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestMaintenance(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dbPath := filepath.Join(t.TempDir(), "temporalite.db")
+	s := newTestServer(t, temporalite.WithDatabaseFilePath(dbPath), temporalite.WithMaintenance(100*time.Millisecond))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	if err := s.WaitReady(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Deleted rows leave free pages behind, which maintenance returns to the file system.
+	// The config matches the server's, so the statements go through its connection.
+	serverConfig := &config.SQL{
+		PluginName:        sqliteplugin.PluginName,
+		DatabaseName:      dbPath,
+		ConnectAttributes: map[string]string{"mode": "rwc"},
+	}
+	if err := litedb.Exec(serverConfig,
+		"CREATE TABLE junk (data BLOB)",
+		"INSERT INTO junk WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n LIMIT 4096) SELECT randomblob(1024) FROM n",
+		"DROP TABLE junk",
+	); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		info, err := os.Stat(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() < 4<<20 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("database file did not shrink, size is %d", info.Size())
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	for _, opts := range [][]temporalite.ServerOption{
+		{temporalite.WithMaintenance(-time.Second)},
+		{temporalite.WithMaintenance(time.Hour), temporalite.WithSQLitePragmas(map[string]string{"auto_vacuum": "full"})},
+	} {
+		if _, err := temporalite.NewServer(append(opts, temporalite.WithPersistenceDisabled())...); err == nil {
+			t.Error("expected error")
+		}
+	}
+}
+
+type describedUIServer struct {
+	liteconfig.NoopUIServer
+}
+
+func (describedUIServer) Addr() string {
+	return "127.0.0.1:8233"
+}
+
+func (describedUIServer) Config() interface{} {
+	return map[string]interface{}{
+		"auth": map[string]interface{}{
+			"providers": []map[string]string{{"clientId": "temporalite", "clientSecret": "hunter2"}},
+		},
+	}
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	s := newTestServer(t,
+		temporalite.WithUI(describedUIServer{}),
+		temporalite.WithDynamicConfigValue("limit.maxIDLength", []dynamicconfig.ConstrainedValue{{Value: 500}}),
+	)
+
+	cfg, err := s.EffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ports chosen by WithDynamicPorts are reported
+	if cfg.FrontendHostPort != s.FrontendHostPort() || strings.HasSuffix(cfg.FrontendHostPort, ":0") {
+		t.Errorf("unexpected frontend address %q", cfg.FrontendHostPort)
+	}
+	if cfg.MetricsAddress == "" || strings.HasSuffix(cfg.MetricsAddress, ":0") {
+		t.Errorf("unexpected metrics address %q", cfg.MetricsAddress)
+	}
+	if cfg.UIAddress != "127.0.0.1:8233" {
+		t.Errorf("unexpected UI address %q", cfg.UIAddress)
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+	if !strings.Contains(out, `"limit.maxIDLength":[{"value":500}]`) {
+		t.Errorf("dynamic config missing from %s", out)
+	}
+	if !strings.Contains(out, `"clientId":"temporalite"`) || strings.Contains(out, "hunter2") {
+		t.Errorf("UI config not redacted in %s", out)
+	}
+	if !strings.Contains(out, `"pluginName":"sqlite"`) {
+		t.Errorf("persistence config missing from %s", out)
+	}
+}
+
+func TestDynamicConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dynamicconfig.yaml")
+	writeFile := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// The file is reloaded when its modification time changes
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(`
+limit.maxIDLength:
+  - value: 100
+frontend.enableSchedules:
+  - value: false
+    constraints:
+      namespace: foo
+`, time.Now())
+
+	s := newTestServer(t,
+		temporalite.WithDynamicConfigFile(path, 5*time.Second),
+		temporalite.WithDynamicConfigValue(dynamicconfig.MaxIDLengthLimit, []dynamicconfig.ConstrainedValue{{Value: 500}}),
+	)
+	defer s.Stop()
+
+	dynamicConfig := func() string {
+		cfg, err := s.EffectiveConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(cfg.DynamicConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// Individual values take precedence over the file
+	expected := `{"frontend.enableSchedules":[{"constraints":{"namespace":"foo"},"value":false}],"limit.maxIDLength":[{"value":500}]}`
+	if got := dynamicConfig(); got != expected {
+		t.Errorf("expected dynamic config %s, got %s", expected, got)
+	}
+
+	writeFile(`
+limit.maxIDLength:
+  - value: 100
+frontend.enableSchedules:
+  - value: true
+`, time.Now().Add(time.Minute))
+
+	expected = `{"frontend.enableSchedules":[{"value":true}],"limit.maxIDLength":[{"value":500}]}`
+	deadline := time.Now().Add(15 * time.Second)
+	for got := dynamicConfig(); got != expected; got = dynamicConfig() {
+		if time.Now().After(deadline) {
+			t.Fatalf("dynamic config file not reloaded: expected %s, got %s", expected, got)
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
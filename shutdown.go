@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	taskqueuepb "go.temporal.io/api/taskqueue/v1"
+	"go.temporal.io/server/common/log/tag"
+	"go.temporal.io/server/common/primitives"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Used by Shutdown when the context has no deadline.
+const defaultShutdownTimeout = time.Minute
+
+// Prefix of task queues polled by the server's own workers.
+const systemTaskQueuePrefix = "temporal-sys-"
+
+// requestTracker counts in-flight frontend requests so that they can be drained
+// before the server is stopped.
+type requestTracker struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight map[string]int
+	idle     chan struct{}
+}
+
+func newRequestTracker() *requestTracker {
+	return &requestTracker{
+		inFlight: make(map[string]int),
+		idle:     make(chan struct{}),
+	}
+}
+
+// intercept is a gRPC interceptor for the frontend service. Once draining has
+// begun, new requests are rejected with codes.Unavailable.
+//
+// Requests issued by the server's own workers are neither tracked nor rejected
+// so that system workflows keep running until the server is stopped.
+func (rt *requestTracker) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if isSystemRequest(req) {
+		return handler(ctx, req)
+	}
+	if !rt.begin(info.FullMethod) {
+		return nil, status.Error(codes.Unavailable, "server is shutting down")
+	}
+	defer rt.end(info.FullMethod)
+	return handler(ctx, req)
+}
+
+func (rt *requestTracker) begin(method string) bool {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.draining {
+		return false
+	}
+	rt.inFlight[method]++
+	return true
+}
+
+func (rt *requestTracker) end(method string) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.inFlight[method]--
+	if rt.inFlight[method] == 0 {
+		delete(rt.inFlight, method)
+	}
+	if rt.draining && len(rt.inFlight) == 0 {
+		close(rt.idle)
+	}
+}
+
+// drain rejects new requests and waits for in-flight requests to complete or
+// for ctx to be done. It returns the number of requests per method that were
+// still in flight when it returned.
+func (rt *requestTracker) drain(ctx context.Context) map[string]int {
+	rt.mu.Lock()
+	if !rt.draining {
+		rt.draining = true
+		if len(rt.inFlight) == 0 {
+			close(rt.idle)
+		}
+	}
+	rt.mu.Unlock()
+
+	select {
+	case <-rt.idle:
+	case <-ctx.Done():
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	remaining := make(map[string]int, len(rt.inFlight))
+	for method, n := range rt.inFlight {
+		remaining[method] = n
+	}
+	return remaining
+}
+
+func isSystemRequest(req interface{}) bool {
+	if r, ok := req.(interface{ GetNamespace() string }); ok && r.GetNamespace() == primitives.SystemLocalNamespace {
+		return true
+	}
+	if r, ok := req.(interface{ GetTaskQueue() *taskqueuepb.TaskQueue }); ok {
+		return strings.HasPrefix(r.GetTaskQueue().GetName(), systemTaskQueuePrefix)
+	}
+	return false
+}
+
+// Shutdown gracefully stops the server.
+//
+// New frontend requests are rejected while in-flight requests, including long polls,
+// are given until the ctx deadline to complete. When ctx has no deadline, they are
+// given one minute. The SQLite WAL is then checkpointed and the server is stopped.
+//
+// The returned error lists the requests that were cut off, if any.
+// Requests are not tracked when frontend gRPC interceptors are overridden through
+// WithUpstreamOptions.
+func (s *Server) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, timeoutFromContext(ctx, defaultShutdownTimeout))
+	defer cancel()
+
+	var errs []string
+	if cutOff := s.requests.drain(ctx); len(cutOff) > 0 {
+		methods := make([]string, 0, len(cutOff))
+		total := 0
+		for method, n := range cutOff {
+			methods = append(methods, fmt.Sprintf("%s (%d)", method, n))
+			total += n
+		}
+		sort.Strings(methods)
+		errs = append(errs, fmt.Sprintf("%d in-flight requests were cut off: %s", total, strings.Join(methods, ", ")))
+	}
+
+	if err := s.checkpoint(); err != nil {
+		s.config.Logger.Warn("Unable to checkpoint SQLite WAL", tag.Error(err))
+		errs = append(errs, fmt.Sprintf("unable to checkpoint SQLite WAL: %v", err))
+	}
+
+	s.Stop()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("shutdown incomplete: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.temporal.io/server/common/config"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+	"github.com/temporalio/temporalite/internal/litedb"
+)
+
+// sqlConfig returns the SQLite config used by the server.
+func (s *Server) sqlConfig() *config.SQL {
+	return s.serverConfig.Persistence.DataStores[liteconfig.PersistenceStoreName].SQL
+}
+
+// fileDataStores returns the SQLite configs of the database files used by cfg.
+func fileDataStores(cfg *config.Config) []*config.SQL {
+	var stores []*config.SQL
+	for _, store := range cfg.Persistence.DataStores {
+		if store.SQL.ConnectAttributes["mode"] != "memory" {
+			stores = append(stores, store.SQL)
+		}
+	}
+	return stores
+}
+
+// checkpoint writes the content of the SQLite WAL files back into the database files.
+// It is a no-op for databases that do not use WAL journaling.
+func (s *Server) checkpoint() error {
+	for _, store := range fileDataStores(s.serverConfig) {
+		if err := litedb.Checkpoint(store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lockDatabases marks the database files of the server as in use until it is
+// stopped, so that `temporalite db restore` refuses to replace them.
+func (s *Server) lockDatabases() error {
+	for _, store := range fileDataStores(s.serverConfig) {
+		release, err := litedb.Lock(store.DatabaseName)
+		if err != nil {
+			s.unlockDatabases()
+			return err
+		}
+		s.databaseLocks = append(s.databaseLocks, release)
+	}
+	return nil
+}
+
+func (s *Server) unlockDatabases() {
+	for _, release := range s.databaseLocks {
+		_ = release()
+	}
+	s.databaseLocks = nil
+}
+
+// Backup writes a consistent copy of the server's database to w.
+//
+// When visibility records are stored in a separate database, they are not included.
+//
+// It is safe to call while the server is running. The copy is a regular SQLite
+// database file which can be used with WithDatabaseFilePath, or restored with
+// the `temporalite db restore` command.
+func (s *Server) Backup(ctx context.Context, w io.Writer) error {
+	return litedb.Backup(ctx, s.sqlConfig(), w)
+}
+
+// Snapshot returns a consistent copy of the server's database.
+//
+// It is meant for servers started with WithPersistenceDisabled, whose state is
+// otherwise lost when the process exits. The snapshot can be passed to
+// WithInitialSnapshot to start another server from the same state, or written
+// to a file and used with WithDatabaseFilePath.
+func (s *Server) Snapshot(ctx context.Context) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.Backup(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// loadSnapshot replaces the content of the database described by cfg with snapshot.
+func loadSnapshot(cfg *config.SQL, snapshot []byte) error {
+	dir, err := os.MkdirTemp("", "temporalite-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	path := filepath.Join(dir, "snapshot.db")
+	if err := os.WriteFile(path, snapshot, 0600); err != nil {
+		return err
+	}
+	if err := litedb.Load(cfg, path); err != nil {
+		return err
+	}
+	// Hosts of the server the snapshot was taken from must not be contacted
+	return litedb.Exec(cfg, "DELETE FROM cluster_membership")
+}
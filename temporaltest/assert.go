@@ -0,0 +1,216 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Timeout of the requests made by the assertion helpers.
+const assertTimeout = 10 * time.Second
+
+// Interval at which WaitForSearchAttribute describes the workflow.
+const searchAttributePollInterval = 50 * time.Millisecond
+
+// ExecuteAndWait starts a workflow with the default client and waits for it to
+// close, whether it succeeds or not. The outcome can be checked with the Assert
+// helpers or with the Get method of the returned run.
+func (ts *TestServer) ExecuteAndWait(ctx context.Context, opts client.StartWorkflowOptions, workflow interface{}, args ...interface{}) client.WorkflowRun {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	run, err := ts.DefaultClient().ExecuteWorkflow(ctx, opts, workflow, args...)
+	if err != nil {
+		ts.fatal(fmt.Errorf("error starting workflow: %w", err))
+	}
+	// Failures of the workflow are reported by the Assert helpers
+	if err := run.Get(ctx, nil); err != nil && ctx.Err() != nil {
+		ts.fatal(fmt.Errorf("error waiting for workflow %s: %w", run.GetID(), err))
+	}
+	return run
+}
+
+// AssertWorkflowCompleted checks that the workflow of the run completed
+// successfully, and reports the failure otherwise. It waits for the workflow to
+// close, following continue-as-new.
+func (ts *TestServer) AssertWorkflowCompleted(run client.WorkflowRun) bool {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), assertTimeout)
+	defer cancel()
+
+	if err := run.Get(ctx, nil); err != nil {
+		ts.errorf("workflow %s did not complete:\n\terror: %v", run.GetID(), err)
+		return false
+	}
+	return true
+}
+
+// AssertWorkflowFailedWith checks that the workflow of the run failed with an
+// application error of type errType, either directly or as the cause of another
+// error such as the failure of an activity. It waits for the workflow to close,
+// following continue-as-new.
+//
+// The type of an application error is the one given to temporal.NewApplicationError,
+// or the Go type name of the error returned by workflow or activity code.
+func (ts *TestServer) AssertWorkflowFailedWith(run client.WorkflowRun, errType string) bool {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), assertTimeout)
+	defer cancel()
+
+	err := run.Get(ctx, nil)
+	if err == nil {
+		ts.errorf("workflow %s completed, expected it to fail with %q", run.GetID(), errType)
+		return false
+	}
+
+	var types []string
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if appErr, ok := e.(*temporal.ApplicationError); ok {
+			if appErr.Type() == errType {
+				return true
+			}
+			types = append(types, appErr.Type())
+		}
+	}
+	ts.errorf("workflow %s did not fail with %q:\n\texpected: %q\n\tactual:   %q\n\terror:    %v", run.GetID(), errType, errType, types, err)
+	return false
+}
+
+// AssertActivityCalled checks that the workflow of the run scheduled the activity
+// name the given number of times, including retries scheduled by the workflow
+// itself but not the retries of the server. Only the history of the run itself is
+// checked, not that of the runs it continued as new to.
+func (ts *TestServer) AssertActivityCalled(run client.WorkflowRun, name string, times int) bool {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	events, ok := ts.history(run)
+	if !ok {
+		return false
+	}
+
+	calls := make(map[string]int)
+	for _, e := range events {
+		if attrs := e.GetActivityTaskScheduledEventAttributes(); attrs != nil {
+			calls[attrs.GetActivityType().GetName()]++
+		}
+	}
+	if calls[name] != times {
+		ts.errorf("workflow %s called activity %q %d times, expected %d:\n\texpected: %v\n\tactual:   %v",
+			run.GetID(), name, calls[name], times, map[string]int{name: times}, calls)
+		return false
+	}
+	return true
+}
+
+// AssertSignalReceived checks that the workflow of the run received the signal
+// name. Only the history of the run itself is checked.
+func (ts *TestServer) AssertSignalReceived(run client.WorkflowRun, name string) bool {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	events, ok := ts.history(run)
+	if !ok {
+		return false
+	}
+
+	signals := make(map[string]int)
+	for _, e := range events {
+		if attrs := e.GetWorkflowExecutionSignaledEventAttributes(); attrs != nil {
+			if attrs.GetSignalName() == name {
+				return true
+			}
+			signals[attrs.GetSignalName()]++
+		}
+	}
+	ts.errorf("workflow %s did not receive signal %q:\n\treceived: %v", run.GetID(), name, signals)
+	return false
+}
+
+// WaitForSearchAttribute waits until the search attribute name of the workflow of
+// the run is equal to value, and reports the last value seen when ctx is done.
+//
+// The search attribute is decoded into a value of the same type as value before
+// they are compared, so value should be a string, int64, float64, bool, time.Time
+// or []string, depending on the type of the search attribute.
+func (ts *TestServer) WaitForSearchAttribute(ctx context.Context, run client.WorkflowRun, name string, value interface{}) bool {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	var last interface{}
+	for {
+		resp, err := ts.DefaultClient().DescribeWorkflowExecution(ctx, run.GetID(), run.GetRunID())
+		if err != nil && ctx.Err() == nil {
+			ts.errorf("error describing workflow %s: %v", run.GetID(), err)
+			return false
+		}
+		if p, ok := resp.GetWorkflowExecutionInfo().GetSearchAttributes().GetIndexedFields()[name]; ok {
+			v := reflect.New(reflect.TypeOf(value))
+			if err := converter.GetDefaultDataConverter().FromPayload(p, v.Interface()); err != nil {
+				ts.errorf("search attribute %q of workflow %s is not a %T: %v", name, run.GetID(), value, err)
+				return false
+			}
+			last = v.Elem().Interface()
+			if reflect.DeepEqual(last, value) {
+				return true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if last == nil {
+				ts.errorf("search attribute %q of workflow %s was not set:\n\texpected: %#v", name, run.GetID(), value)
+			} else {
+				ts.errorf("search attribute %q of workflow %s did not match:\n\texpected: %#v\n\tactual:   %#v", name, run.GetID(), value, last)
+			}
+			return false
+		case <-time.After(searchAttributePollInterval):
+		}
+	}
+}
+
+// history returns the events of the run, reporting the error if they cannot be read.
+func (ts *TestServer) history(run client.WorkflowRun) ([]*historypb.HistoryEvent, bool) {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), assertTimeout)
+	defer cancel()
+
+	var events []*historypb.HistoryEvent
+	iter := ts.DefaultClient().GetWorkflowHistory(ctx, run.GetID(), run.GetRunID(), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		e, err := iter.Next()
+		if err != nil {
+			ts.errorf("error reading history of workflow %s: %v", run.GetID(), err)
+			return nil, false
+		}
+		events = append(events, e)
+	}
+	return events, true
+}
+
+// errorf reports an assertion failure to the test, or panics without one.
+func (ts *TestServer) errorf(format string, args ...interface{}) {
+	if ts.t == nil {
+		panic(fmt.Sprintf(format, args...))
+	}
+	ts.t.Helper()
+	ts.t.Errorf(format, args...)
+}
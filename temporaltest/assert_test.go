@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/temporalio/temporalite/internal/examples/helloworld"
+	"github.com/temporalio/temporalite/temporaltest"
+)
+
+const statusSearchAttribute = "AssertionStatus"
+
+func failingWorkflow(ctx workflow.Context) error {
+	return temporal.NewApplicationError("boom", "BoomError")
+}
+
+func signaledWorkflow(ctx workflow.Context) error {
+	if err := workflow.UpsertSearchAttributes(ctx, map[string]interface{}{statusSearchAttribute: "waiting"}); err != nil {
+		return err
+	}
+	workflow.GetSignalChannel(ctx, "go").Receive(ctx, nil)
+	return nil
+}
+
+// recordingT records the errors reported by assertions instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingT) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	ts := temporaltest.NewServer(temporaltest.WithT(t))
+	ts.NewWorker("assertions", func(registry worker.Registry) {
+		helloworld.RegisterWorkflowsAndActivities(registry)
+		registry.RegisterWorkflow(failingWorkflow)
+		registry.RegisterWorkflow(signaledWorkflow)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	opts := client.StartWorkflowOptions{TaskQueue: "assertions"}
+
+	run := ts.ExecuteAndWait(ctx, opts, helloworld.Greet, "world")
+	ts.AssertWorkflowCompleted(run)
+	ts.AssertActivityCalled(run, "PickGreeting", 1)
+	ts.AssertActivityCalled(run, "TestIntercept", 0)
+
+	run = ts.ExecuteAndWait(ctx, opts, failingWorkflow)
+	ts.AssertWorkflowFailedWith(run, "BoomError")
+
+	_, err := ts.DefaultClient().OperatorService().AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+		SearchAttributes: map[string]enums.IndexedValueType{
+			statusSearchAttribute: enums.INDEXED_VALUE_TYPE_KEYWORD,
+		},
+	})
+	var exists *serviceerror.AlreadyExists
+	if err != nil && !errors.As(err, &exists) {
+		t.Fatal(err)
+	}
+	run, err = ts.DefaultClient().ExecuteWorkflow(ctx, opts, signaledWorkflow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts.WaitForSearchAttribute(ctx, run, statusSearchAttribute, "waiting")
+	if err := ts.DefaultClient().SignalWorkflow(ctx, run.GetID(), "", "go", nil); err != nil {
+		t.Fatal(err)
+	}
+	ts.AssertWorkflowCompleted(run)
+	ts.AssertSignalReceived(run, "go")
+}
+
+func TestAssertionFailures(t *testing.T) {
+	rt := &recordingT{TB: t}
+	ts := temporaltest.NewServer(temporaltest.WithTB(rt))
+	ts.NewWorker("assertions", func(registry worker.Registry) {
+		helloworld.RegisterWorkflowsAndActivities(registry)
+		registry.RegisterWorkflow(failingWorkflow)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	opts := client.StartWorkflowOptions{TaskQueue: "assertions"}
+
+	run := ts.ExecuteAndWait(ctx, opts, helloworld.Greet, "world")
+	for _, tc := range []struct {
+		ok       bool
+		expected string
+	}{
+		{ts.AssertWorkflowFailedWith(run, "BoomError"), `completed, expected it to fail with "BoomError"`},
+		{ts.AssertActivityCalled(run, "PickGreeting", 2), "expected: map[PickGreeting:2]\n\tactual:   map[PickGreeting:1]"},
+		{ts.AssertSignalReceived(run, "go"), `did not receive signal "go"`},
+	} {
+		if tc.ok {
+			t.Errorf("expected assertion to fail with %q", tc.expected)
+		}
+		if !strings.Contains(strings.Join(rt.errors, "\n"), tc.expected) {
+			t.Errorf("expected error %q, got %q", tc.expected, rt.errors)
+		}
+	}
+
+	run = ts.ExecuteAndWait(ctx, opts, failingWorkflow)
+	if ts.AssertWorkflowCompleted(run) {
+		t.Error("expected assertion to fail")
+	}
+	if ts.AssertWorkflowFailedWith(run, "OtherError") {
+		t.Error("expected assertion to fail")
+	}
+	if expected := `actual:   ["BoomError"]`; !strings.Contains(rt.errors[len(rt.errors)-1], expected) {
+		t.Errorf("expected error %q, got %q", expected, rt.errors[len(rt.errors)-1])
+	}
+}
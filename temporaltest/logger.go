@@ -9,7 +9,7 @@ import (
 )
 
 type testLogger struct {
-	t *testing.T
+	t testing.TB
 }
 
 func (tl *testLogger) logLevel(lvl, msg string, keyvals ...interface{}) {
@@ -22,8 +22,18 @@ type TestServerOption interface {
 // If this option is specified, then server will automatically be stopped when the
 // test completes.
 func WithT(t *testing.T) TestServerOption {
+	if t == nil {
+		// Don't wrap a nil pointer in a non-nil testing.TB
+		return WithTB(nil)
+	}
+	return WithTB(t)
+}
+
+// WithTB is like WithT, but accepts any testing.TB, such as the *testing.B of a
+// benchmark or the *testing.F of a fuzz target.
+func WithTB(tb testing.TB) TestServerOption {
 	return newApplyFuncContainer(func(server *TestServer) {
-		server.t = t
+		server.t = tb
 	})
 }
 
@@ -52,6 +62,39 @@ func WithTemporaliteOptions(options ...temporalite.ServerOption) TestServerOptio
 	})
 }
 
+// WithTimeSkipping makes workflow timers, including those of workflow.Sleep, fire
+// without waiting for their duration, and enables TestServer.SkipTime and
+// TestServer.Sleep, so that workflows with long timers can be tested end-to-end.
+//
+// Time is skipped by the workers and clients created by the TestServer, not by the
+// server, whose clock cannot be replaced in Temporal 1.19: a timer started while
+// no activity, local activity or child workflow of the workflow is in flight is
+// started on the server with a minimal duration, and workflow.Now advances to its
+// deadline when it fires. Timers started while one is in flight run on the wall
+// clock, so that they can still expire after it. Time skipped by SkipTime and Sleep
+// is passed to workflows in the headers of the starts and signals of the test
+// clients. Activity and workflow timeouts, retry intervals and retention are not
+// affected, and no event is added to workflow histories.
+func WithTimeSkipping() TestServerOption {
+	return newApplyFuncContainer(func(server *TestServer) {
+		server.timeSkipping = true
+	})
+}
+
+// WithReplayCheck makes Stop replay the history of every workflow run in the test
+// namespace with the workflows registered by NewWorker and NewWorkerWithOptions, and
+// report the runs that fail to replay, such as those whose workflow code is not
+// deterministic.
+//
+// Runs are replayed with the workflows registered on the task queue they ran on, by
+// the first worker created on it, and with the interceptors and data converter of
+// that worker. Runs on task queues without such a worker are not replayed.
+func WithReplayCheck() TestServerOption {
+	return newApplyFuncContainer(func(server *TestServer) {
+		server.replayCheck = true
+	})
+}
+
 type applyFuncContainer struct {
 	applyInternal func(*TestServer)
 }
@@ -0,0 +1,159 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
+	workflowpb "go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/activity"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+)
+
+// Timeout of the requests made to read the histories replayed by the replay check.
+const replayCheckTimeout = 30 * time.Second
+
+// replayRegistry registers the workflows of a worker with a replayer. Activities
+// are not needed for replay.
+type replayRegistry struct {
+	worker.WorkflowReplayer
+}
+
+func (replayRegistry) RegisterActivity(interface{}) {}
+
+func (replayRegistry) RegisterActivityWithOptions(interface{}, activity.RegisterOptions) {}
+
+// addReplayer registers the workflows of a new worker with a replayer for its task
+// queue, when the WithReplayCheck option is set.
+func (ts *TestServer) addReplayer(taskQueue string, registerFunc func(registry worker.Registry), opts worker.Options) {
+	if !ts.replayCheck {
+		return
+	}
+	// Registering the same workflows again would panic
+	if _, ok := ts.replayers[taskQueue]; ok {
+		return
+	}
+
+	// Workers also use the interceptors of their client
+	var interceptors []interceptor.WorkerInterceptor
+	for _, i := range ts.defaultClientOptions.Interceptors {
+		if i, ok := i.(interceptor.WorkerInterceptor); ok {
+			interceptors = append(interceptors, i)
+		}
+	}
+	replayer, err := worker.NewWorkflowReplayerWithOptions(worker.WorkflowReplayerOptions{
+		DataConverter:               ts.defaultClientOptions.DataConverter,
+		FailureConverter:            ts.defaultClientOptions.FailureConverter,
+		Interceptors:                append(interceptors, opts.Interceptors...),
+		DisableRegistrationAliasing: opts.DisableRegistrationAliasing,
+	})
+	if err != nil {
+		ts.fatal(fmt.Errorf("error creating replayer: %w", err))
+	}
+	registerFunc(replayRegistry{replayer})
+
+	if ts.replayers == nil {
+		ts.replayers = make(map[string]worker.WorkflowReplayer)
+	}
+	ts.replayers[taskQueue] = replayer
+}
+
+// checkReplay replays the workflow runs of the test namespace, and reports those
+// that fail to replay.
+func (ts *TestServer) checkReplay() {
+	if ts.t != nil {
+		ts.t.Helper()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), replayCheckTimeout)
+	defer cancel()
+
+	executions, err := ts.executions(ctx)
+	if err != nil {
+		ts.fatal(fmt.Errorf("error listing workflows to replay: %w", err))
+	}
+	for _, e := range executions {
+		var events []*historypb.HistoryEvent
+		iter := ts.DefaultClient().GetWorkflowHistory(ctx, e.GetWorkflowId(), e.GetRunId(), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+		for iter.HasNext() {
+			event, err := iter.Next()
+			if err != nil {
+				ts.fatal(fmt.Errorf("error reading history of workflow %s: %w", e.GetWorkflowId(), err))
+			}
+			events = append(events, event)
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		taskQueue := events[0].GetWorkflowExecutionStartedEventAttributes().GetTaskQueue().GetName()
+		replayer, ok := ts.replayers[taskQueue]
+		if !ok {
+			continue
+		}
+		if err := replayer.ReplayWorkflowHistory(&testLogger{ts.t}, &historypb.History{Events: events}); err != nil {
+			ts.errorf("workflow %s (run %s) failed to replay:\n\terror: %v", e.GetWorkflowId(), e.GetRunId(), err)
+		}
+	}
+}
+
+// executions returns the workflow runs of the test namespace: those listed by the
+// visibility store, and the latest runs of the workflows started through the test
+// clients, which may not be listed yet.
+func (ts *TestServer) executions(ctx context.Context) ([]*commonpb.WorkflowExecution, error) {
+	var executions []*commonpb.WorkflowExecution
+	listed := make(map[string]bool)
+	add := func(infos []*workflowpb.WorkflowExecutionInfo) {
+		for _, info := range infos {
+			executions = append(executions, info.GetExecution())
+			listed[info.GetExecution().GetWorkflowId()] = true
+		}
+	}
+
+	c := ts.DefaultClient()
+	var token []byte
+	for {
+		resp, err := c.ListOpenWorkflow(ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{
+			Namespace:     ts.defaultTestNamespace,
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		add(resp.GetExecutions())
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			break
+		}
+	}
+	for {
+		resp, err := c.ListClosedWorkflow(ctx, &workflowservice.ListClosedWorkflowExecutionsRequest{
+			Namespace:     ts.defaultTestNamespace,
+			NextPageToken: token,
+		})
+		if err != nil {
+			return nil, err
+		}
+		add(resp.GetExecutions())
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			break
+		}
+	}
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, id := range ts.startedWorkflows[ts.defaultTestNamespace] {
+		if !listed[id] {
+			listed[id] = true
+			executions = append(executions, &commonpb.WorkflowExecution{WorkflowId: id})
+		}
+	}
+	return executions, nil
+}
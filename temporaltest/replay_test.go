@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+
+	"github.com/temporalio/temporalite/internal/examples/helloworld"
+	"github.com/temporalio/temporalite/temporaltest"
+)
+
+// Number of activities executed by changingWorkflow, changed between the execution
+// and the replay of the workflow to make it non-deterministic.
+var changingWorkflowActivities int
+
+func changingWorkflow(ctx workflow.Context) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{ScheduleToCloseTimeout: time.Second})
+	for i := 0; i < changingWorkflowActivities; i++ {
+		if err := workflow.ExecuteActivity(ctx, helloworld.PickGreeting).Get(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestReplayCheck(t *testing.T) {
+	ts := temporaltest.NewServer(temporaltest.WithT(t), temporaltest.WithReplayCheck())
+	ts.NewWorker("hello_world", func(registry worker.Registry) {
+		helloworld.RegisterWorkflowsAndActivities(registry)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run := ts.ExecuteAndWait(ctx, client.StartWorkflowOptions{TaskQueue: "hello_world"}, helloworld.Greet, "world")
+	ts.AssertWorkflowCompleted(run)
+}
+
+func TestReplayCheckNonDeterminism(t *testing.T) {
+	changingWorkflowActivities = 1
+
+	rt := &recordingT{TB: t}
+	// Cleanups run in reverse order, so this one runs after the replay check
+	t.Cleanup(func() {
+		if len(rt.errors) != 1 || !strings.Contains(rt.errors[0], "failed to replay") {
+			t.Errorf("expected replay to fail, got %q", rt.errors)
+		}
+	})
+	ts := temporaltest.NewServer(temporaltest.WithTB(rt), temporaltest.WithReplayCheck())
+	ts.NewWorker("changing", func(registry worker.Registry) {
+		registry.RegisterWorkflow(changingWorkflow)
+		registry.RegisterActivity(helloworld.PickGreeting)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	run := ts.ExecuteAndWait(ctx, client.StartWorkflowOptions{TaskQueue: "changing"}, changingWorkflow)
+	ts.AssertWorkflowCompleted(run)
+
+	changingWorkflowActivities = 2
+}
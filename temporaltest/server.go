@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
@@ -26,24 +27,39 @@ type TestServer struct {
 	defaultClient        client.Client
 	clients              []client.Client
 	workers              []worker.Worker
-	t                    *testing.T
+	t                    testing.TB
 	defaultClientOptions client.Options
 	defaultWorkerOptions worker.Options
 	serverOptions        []temporalite.ServerOption
+	timeSkipping         bool
+	replayCheck          bool
+	replayers            map[string]worker.WorkflowReplayer
+	// Set when the server is shared with other tests through a SharedServer
+	shared bool
+
+	mu               sync.Mutex
+	startedWorkflows map[string][]string
+	// First client of each namespace, used to describe the started workflows
+	namespaceClients map[string]client.Client
+	// Time skipped by SkipTime
+	timeOffset time.Duration
 }
 
 func (ts *TestServer) fatal(err error) {
 	if ts.t == nil {
 		panic(err)
 	}
+	ts.t.Helper()
 	ts.t.Fatal(err)
 }
 
 // NewWorker registers and starts a Temporal worker on the specified task queue.
 func (ts *TestServer) NewWorker(taskQueue string, registerFunc func(registry worker.Registry)) worker.Worker {
-	w := worker.New(ts.DefaultClient(), taskQueue, ts.defaultWorkerOptions)
+	opts := ts.withTimeSkipping(ts.defaultWorkerOptions)
+	w := worker.New(ts.DefaultClient(), taskQueue, opts)
 	registerFunc(w)
 	ts.workers = append(ts.workers, w)
+	ts.addReplayer(taskQueue, registerFunc, opts)
 
 	if err := w.Start(); err != nil {
 		ts.fatal(err)
@@ -59,9 +75,11 @@ func (ts *TestServer) NewWorker(taskQueue string, registerFunc func(registry wor
 func (ts *TestServer) NewWorkerWithOptions(taskQueue string, registerFunc func(registry worker.Registry), opts worker.Options) worker.Worker {
 	opts.WorkflowPanicPolicy = worker.FailWorkflow
 
+	opts = ts.withTimeSkipping(opts)
 	w := worker.New(ts.DefaultClient(), taskQueue, opts)
 	registerFunc(w)
 	ts.workers = append(ts.workers, w)
+	ts.addReplayer(taskQueue, registerFunc, opts)
 
 	if err := w.Start(); err != nil {
 		ts.fatal(err)
@@ -80,6 +98,11 @@ func (ts *TestServer) DefaultClient() client.Client {
 	return ts.defaultClient
 }
 
+// Namespace returns the pre-registered test namespace used by the default client and workers.
+func (ts *TestServer) Namespace() string {
+	return ts.defaultTestNamespace
+}
+
 // NewClientWithOptions returns a new Temporal client configured for making requests to the server.
 //
 // If no namespace option is set it will use a pre-registered test namespace.
@@ -91,6 +114,15 @@ func (ts *TestServer) NewClientWithOptions(opts client.Options) client.Client {
 	if opts.Logger == nil {
 		opts.Logger = &testLogger{ts.t}
 	}
+	if ts.replayCheck || ts.shared || ts.timeSkipping {
+		opts.Interceptors = append(opts.Interceptors[:len(opts.Interceptors):len(opts.Interceptors)], &trackingClientInterceptor{
+			ts:        ts,
+			namespace: opts.Namespace,
+		})
+	}
+	if ts.timeSkipping {
+		opts.Interceptors = append(opts.Interceptors[:len(opts.Interceptors):len(opts.Interceptors)], &timeSkippingClientInterceptor{ts: ts})
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -101,31 +133,51 @@ func (ts *TestServer) NewClientWithOptions(opts client.Options) client.Client {
 	}
 
 	ts.clients = append(ts.clients, c)
-
+	ts.mu.Lock()
+	if ts.namespaceClients == nil {
+		ts.namespaceClients = make(map[string]client.Client)
+	}
+	if _, ok := ts.namespaceClients[opts.Namespace]; !ok {
+		ts.namespaceClients[opts.Namespace] = c
+	}
+	ts.mu.Unlock()
 	return c
 }
 
 // Stop closes test clients and shuts down the server.
+//
+// When the TestServer was returned by SharedServer.NewServer, Stop terminates the
+// workflows still running in the test namespace instead of shutting down the server.
+// With the WithReplayCheck option, Stop first replays the workflows of the test.
 func (ts *TestServer) Stop() {
+	if ts.replayCheck && len(ts.clients) > 0 {
+		ts.checkReplay()
+	}
+	if ts.shared && len(ts.clients) > 0 {
+		if err := ts.terminateWorkflows(); err != nil {
+			ts.fatal(fmt.Errorf("error terminating workflows: %w", err))
+		}
+	}
 	for _, w := range ts.workers {
 		w.Stop()
 	}
 	for _, c := range ts.clients {
 		c.Close()
 	}
-	ts.server.Stop()
+	if !ts.shared {
+		ts.server.Stop()
+	}
 }
 
 // NewServer starts and returns a new TestServer.
 //
-// If not specifying the WithT option, the caller should execute Stop when finished to close
+// If not specifying the WithT or WithTB option, the caller should execute Stop when finished to close
 // the server and release resources.
 func NewServer(opts ...TestServerOption) *TestServer {
 	rand.Seed(time.Now().UnixNano())
-	testNamespace := fmt.Sprintf("temporaltest-%d", rand.Intn(999999))
 
 	ts := TestServer{
-		defaultTestNamespace: testNamespace,
+		defaultTestNamespace: newTestNamespace(),
 	}
 
 	// Apply options
@@ -161,5 +213,15 @@ func NewServer(opts ...TestServerOption) *TestServer {
 		}
 	}()
 
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.WaitReady(ctx); err != nil {
+		ts.fatal(fmt.Errorf("error waiting for server: %w", err))
+	}
+
 	return &ts
 }
+
+func newTestNamespace() string {
+	return fmt.Sprintf("temporaltest-%d", rand.Intn(999999))
+}
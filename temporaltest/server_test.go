@@ -11,9 +11,11 @@ import (
 	"time"
 
 	"go.temporal.io/api/enums/v1"
+	historypb "go.temporal.io/api/history/v1"
 	"go.temporal.io/api/operatorservice/v1"
 	"go.temporal.io/sdk/client"
 	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
 
 	"github.com/temporalio/temporalite/internal/examples/helloworld"
 	"github.com/temporalio/temporalite/temporaltest"
@@ -222,9 +224,197 @@ func TestSearchAttributeCacheDisabled(t *testing.T) {
 	}
 }
 
+func sleepWorkflow(ctx workflow.Context, d time.Duration) (time.Duration, error) {
+	start := workflow.Now(ctx)
+	if err := workflow.Sleep(ctx, d); err != nil {
+		return 0, err
+	}
+	return workflow.Now(ctx).Sub(start), nil
+}
+
+func TestTimeSkipping(t *testing.T) {
+	// Skipped timers are replayed with the time skipping interceptor
+	ts := temporaltest.NewServer(temporaltest.WithT(t), temporaltest.WithTimeSkipping(), temporaltest.WithReplayCheck())
+
+	ts.NewWorker("time_skipping", func(registry worker.Registry) {
+		registry.RegisterWorkflow(sleepWorkflow)
+		registry.RegisterWorkflow(workflow.Sleep)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	wfr, err := ts.DefaultClient().ExecuteWorkflow(
+		ctx,
+		client.StartWorkflowOptions{TaskQueue: "time_skipping"},
+		sleepWorkflow,
+		24*time.Hour,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var slept time.Duration
+	if err := wfr.Get(ctx, &slept); err != nil {
+		t.Fatal(err)
+	}
+	if slept < 24*time.Hour {
+		t.Fatalf("expected workflow to sleep for 24h, slept for %s", slept)
+	}
+
+	// Histories have the same events as without skipping, so that workflows which
+	// do not depend on workflow.Now replay without the interceptor
+	wfr, err = ts.DefaultClient().ExecuteWorkflow(
+		ctx,
+		client.StartWorkflowOptions{TaskQueue: "time_skipping"},
+		workflow.Sleep,
+		24*time.Hour,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wfr.Get(ctx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []*historypb.HistoryEvent
+	iter := ts.DefaultClient().GetWorkflowHistory(ctx, wfr.GetID(), wfr.GetRunID(), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch event.GetEventType() {
+		case enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED, enums.EVENT_TYPE_TIMER_CANCELED:
+			t.Errorf("unexpected %s event", event.GetEventType())
+		}
+		events = append(events, event)
+	}
+	replayer := worker.NewWorkflowReplayer()
+	replayer.RegisterWorkflow(workflow.Sleep)
+	if err := replayer.ReplayWorkflowHistory(nil, &historypb.History{Events: events}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitActivity(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// raceWorkflow returns whether an activity completes before a timer of d.
+func raceWorkflow(ctx workflow.Context, d time.Duration) (bool, error) {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{StartToCloseTimeout: time.Minute})
+	activityDone := false
+	workflow.NewSelector(ctx).
+		AddFuture(workflow.ExecuteActivity(ctx, waitActivity, 100*time.Millisecond), func(workflow.Future) {
+			activityDone = true
+		}).
+		AddFuture(workflow.NewTimer(ctx, d), func(workflow.Future) {}).
+		Select(ctx)
+	return activityDone, nil
+}
+
+func TestTimeSkippingActivityInFlight(t *testing.T) {
+	ts := temporaltest.NewServer(temporaltest.WithT(t), temporaltest.WithTimeSkipping())
+
+	ts.NewWorker("time_skipping", func(registry worker.Registry) {
+		registry.RegisterWorkflow(raceWorkflow)
+		registry.RegisterActivity(waitActivity)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	wfr, err := ts.DefaultClient().ExecuteWorkflow(
+		ctx,
+		client.StartWorkflowOptions{TaskQueue: "time_skipping"},
+		raceWorkflow,
+		time.Hour,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Timers started while an activity is in flight are not skipped
+	var activityDone bool
+	if err := wfr.Get(ctx, &activityDone); err != nil {
+		t.Fatal(err)
+	}
+	if !activityDone {
+		t.Fatal("expected activity to complete before the timer")
+	}
+}
+
+type wakeTimes struct {
+	Start, Wake time.Time
+}
+
+// wakeWorkflow returns the time seen when it starts and when it is signaled.
+func wakeWorkflow(ctx workflow.Context) (wakeTimes, error) {
+	times := wakeTimes{Start: workflow.Now(ctx)}
+	workflow.GetSignalChannel(ctx, "wake").Receive(ctx, nil)
+	times.Wake = workflow.Now(ctx)
+	return times, nil
+}
+
+func TestSleepAndSkipTime(t *testing.T) {
+	ts := temporaltest.NewServer(temporaltest.WithT(t), temporaltest.WithTimeSkipping(), temporaltest.WithReplayCheck())
+
+	ts.NewWorker("time_skipping", func(registry worker.Registry) {
+		registry.RegisterWorkflow(wakeWorkflow)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ts.SkipTime(24 * time.Hour)
+	before := time.Now()
+	wfr, err := ts.DefaultClient().ExecuteWorkflow(
+		ctx,
+		client.StartWorkflowOptions{TaskQueue: "time_skipping"},
+		wakeWorkflow,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts.Sleep(2 * time.Hour)
+	if err := ts.DefaultClient().SignalWorkflow(ctx, wfr.GetID(), "", "wake", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var times wakeTimes
+	if err := wfr.Get(ctx, &times); err != nil {
+		t.Fatal(err)
+	}
+	if times.Start.Before(before.Add(24 * time.Hour)) {
+		t.Errorf("expected workflow to start 24h in the future, started at %s", times.Start)
+	}
+	if slept := times.Wake.Sub(times.Start); slept < 2*time.Hour {
+		t.Errorf("expected workflow to be woken up after 2h, woken up after %s", slept)
+	}
+
+	// The skipped time is carried by the existing events
+	iter := ts.DefaultClient().GetWorkflowHistory(ctx, wfr.GetID(), wfr.GetRunID(), false, enums.HISTORY_EVENT_FILTER_TYPE_ALL_EVENT)
+	for iter.HasNext() {
+		event, err := iter.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch event.GetEventType() {
+		case enums.EVENT_TYPE_MARKER_RECORDED, enums.EVENT_TYPE_TIMER_STARTED:
+			t.Errorf("unexpected %s event", event.GetEventType())
+		}
+	}
+}
+
 func BenchmarkRunWorkflow(b *testing.B) {
-	ts := temporaltest.NewServer()
-	defer ts.Stop()
+	ts := temporaltest.NewServer(temporaltest.WithTB(b))
 
 	ts.NewWorker("hello_world", func(registry worker.Registry) {
 		helloworld.RegisterWorkflowsAndActivities(registry)
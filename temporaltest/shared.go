@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/server/common/dynamicconfig"
+
+	"github.com/temporalio/temporalite"
+)
+
+const (
+	// Retention period of the namespaces created for each test by a SharedServer.
+	sharedNamespaceRetention = 24 * time.Hour
+	// Interval at which the services of a SharedServer pick up new namespaces. The
+	// server default of 10s would dominate the time of short tests.
+	sharedNamespaceRefreshInterval = 100 * time.Millisecond
+)
+
+// A SharedServer is a Temporal server shared by the tests of a package, so that the
+// server starts once per package rather than once per test.
+//
+// Each test gets its own TestServer from NewServer, with a new namespace, its own
+// clients and workers, so that tests calling t.Parallel stay isolated:
+//
+//	var shared *temporaltest.SharedServer
+//
+//	func TestMain(m *testing.M) {
+//		shared = temporaltest.NewSharedServer(m)
+//		os.Exit(shared.Run())
+//	}
+//
+//	func TestWorkflow(t *testing.T) {
+//		t.Parallel()
+//		ts := shared.NewServer(t)
+//		...
+//	}
+type SharedServer struct {
+	m      *testing.M
+	opts   []TestServerOption
+	server *TestServer
+	client client.Client
+}
+
+// NewSharedServer starts a server to be shared by the tests run by m.
+//
+// The options apply to the TestServer of each test, except for
+// WithTemporaliteOptions, which configures the shared server.
+func NewSharedServer(m *testing.M, opts ...TestServerOption) *SharedServer {
+	server := NewServer(append([]TestServerOption{
+		WithTemporaliteOptions(temporalite.WithDynamicConfigValue(
+			dynamicconfig.NamespaceCacheRefreshInterval,
+			[]dynamicconfig.ConstrainedValue{{Value: sharedNamespaceRefreshInterval}},
+		)),
+	}, opts...)...)
+	return &SharedServer{
+		m:      m,
+		opts:   opts,
+		server: server,
+		client: server.DefaultClient(),
+	}
+}
+
+// Run runs the tests and stops the server. It returns the exit code to pass to
+// os.Exit.
+func (s *SharedServer) Run() int {
+	defer s.Stop()
+	return s.m.Run()
+}
+
+// Stop shuts down the server. It is called by Run.
+func (s *SharedServer) Stop() {
+	s.server.Stop()
+}
+
+// NewServer returns a TestServer for the test t, connected to the shared server.
+//
+// Its default client and workers use a namespace registered for the test. The
+// options apply after those given to NewSharedServer, and WithTemporaliteOptions
+// is ignored. When the test completes, the workflows still running in the
+// namespace are terminated, and the clients and workers of the TestServer are
+// closed.
+func (s *SharedServer) NewServer(t testing.TB, opts ...TestServerOption) *TestServer {
+	ts := &TestServer{
+		server: s.server.server,
+		shared: true,
+	}
+	for _, opt := range s.opts {
+		opt.apply(ts)
+	}
+	for _, opt := range opts {
+		opt.apply(ts)
+	}
+	WithTB(t).apply(ts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	namespace, err := s.registerNamespace(ctx)
+	if err != nil {
+		ts.fatal(fmt.Errorf("error registering namespace: %w", err))
+	}
+	ts.defaultTestNamespace = namespace
+
+	t.Cleanup(func() {
+		ts.Stop()
+	})
+
+	return ts
+}
+
+// registerNamespace registers a namespace with a new random name, and waits for
+// the services to pick it up.
+func (s *SharedServer) registerNamespace(ctx context.Context) (string, error) {
+	var namespace string
+	for {
+		namespace = newTestNamespace()
+		_, err := s.client.WorkflowService().RegisterNamespace(ctx, &workflowservice.RegisterNamespaceRequest{
+			Namespace:                        namespace,
+			WorkflowExecutionRetentionPeriod: durationPtr(sharedNamespaceRetention),
+		})
+		var exists *serviceerror.NamespaceAlreadyExists
+		if errors.As(err, &exists) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		break
+	}
+
+	// Each service refreshes its own namespace cache. Once the frontend has picked up
+	// the namespace, wait for another refresh of the other services.
+	wait := func(d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+	for {
+		_, err := s.client.WorkflowService().DescribeWorkflowExecution(ctx, &workflowservice.DescribeWorkflowExecutionRequest{
+			Namespace: namespace,
+			Execution: &commonpb.WorkflowExecution{WorkflowId: namespace},
+		})
+		var notFound *serviceerror.NamespaceNotFound
+		if !errors.As(err, &notFound) {
+			break
+		}
+		if err := wait(sharedNamespaceRefreshInterval / 2); err != nil {
+			return "", err
+		}
+	}
+	if err := wait(2 * sharedNamespaceRefreshInterval); err != nil {
+		return "", err
+	}
+	return namespace, nil
+}
+
+// terminateWorkflows terminates the workflows running in the test namespace: those
+// listed by the visibility store, and those started through the test clients,
+// which may not be listed yet.
+func (ts *TestServer) terminateWorkflows() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ts.mu.Lock()
+	ids := append([]string(nil), ts.startedWorkflows[ts.defaultTestNamespace]...)
+	ts.mu.Unlock()
+
+	c := ts.DefaultClient()
+	var token []byte
+	for {
+		resp, err := c.ListOpenWorkflow(ctx, &workflowservice.ListOpenWorkflowExecutionsRequest{
+			Namespace:     ts.defaultTestNamespace,
+			NextPageToken: token,
+		})
+		if err != nil {
+			return err
+		}
+		for _, e := range resp.GetExecutions() {
+			ids = append(ids, e.GetExecution().GetWorkflowId())
+		}
+		if token = resp.GetNextPageToken(); len(token) == 0 {
+			break
+		}
+	}
+
+	terminated := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if terminated[id] {
+			continue
+		}
+		terminated[id] = true
+		// Workflows that have completed are not found
+		err := c.TerminateWorkflow(ctx, id, "", "test completed")
+		var notFound *serviceerror.NotFound
+		if err != nil && !errors.As(err, &notFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package sharedtest_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+
+	"github.com/temporalio/temporalite/internal/examples/helloworld"
+	"github.com/temporalio/temporalite/temporaltest"
+)
+
+// The tests of this package share a server. They are kept apart from the other
+// temporaltest tests, which would otherwise all pay for its startup.
+var shared *temporaltest.SharedServer
+
+func TestMain(m *testing.M) {
+	shared = temporaltest.NewSharedServer(m)
+	os.Exit(shared.Run())
+}
+
+func TestSharedServer(t *testing.T) {
+	namespaces := make(chan string, 3)
+	t.Run("group", func(t *testing.T) {
+		for i := 0; i < cap(namespaces); i++ {
+			t.Run(fmt.Sprintf("test-%d", i), func(t *testing.T) {
+				t.Parallel()
+				ts := shared.NewServer(t)
+				namespaces <- ts.Namespace()
+
+				ts.NewWorker("hello_world", func(registry worker.Registry) {
+					helloworld.RegisterWorkflowsAndActivities(registry)
+				})
+
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				defer cancel()
+
+				wfr, err := ts.DefaultClient().ExecuteWorkflow(
+					ctx,
+					client.StartWorkflowOptions{ID: "greet", TaskQueue: "hello_world"},
+					helloworld.Greet,
+					"world",
+				)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var result string
+				if err := wfr.Get(ctx, &result); err != nil {
+					t.Fatal(err)
+				}
+				if result != "Hello world" {
+					t.Fatalf("unexpected result: %q", result)
+				}
+
+				// Left running, to be terminated on cleanup
+				if _, err := ts.DefaultClient().ExecuteWorkflow(
+					ctx,
+					client.StartWorkflowOptions{ID: "unfinished", TaskQueue: "no_worker"},
+					helloworld.Greet,
+					"world",
+				); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	})
+	close(namespaces)
+
+	ts := shared.NewServer(t)
+	seen := make(map[string]bool)
+	for ns := range namespaces {
+		if seen[ns] || ns == ts.Namespace() {
+			t.Fatalf("namespace %q used by several tests", ns)
+		}
+		seen[ns] = true
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		c := ts.NewClientWithOptions(client.Options{Namespace: ns})
+		resp, err := c.DescribeWorkflowExecution(ctx, "unfinished", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := resp.GetWorkflowExecutionInfo().GetStatus(); status != enums.WORKFLOW_EXECUTION_STATUS_TERMINATED {
+			t.Errorf("expected workflow in namespace %q to be terminated, got %s", ns, status)
+		}
+	}
+}
@@ -0,0 +1,373 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/worker"
+	"go.temporal.io/sdk/workflow"
+)
+
+const (
+	// Duration of the server timers started for skipped timers. The server does
+	// not accept timers without a duration.
+	skippedTimerDuration = time.Millisecond
+	// Name of the header carrying the time skipped by SkipTime, set on the
+	// workflow starts and signals of the test clients.
+	timeOffsetHeader = "temporaltest-time-offset"
+	// Interval at which Sleep checks whether workflows are idle.
+	idlePollInterval = 50 * time.Millisecond
+)
+
+// SkipTime advances the clock of the TestServer by d. It requires the
+// WithTimeSkipping option.
+//
+// Workflows see the skipped time through workflow.Now from the next start or
+// signal they receive from a test client, and pass it on to their child workflows
+// and continued runs. The clock of the server is not changed, so server timers,
+// timeouts and retention are not affected.
+func (ts *TestServer) SkipTime(d time.Duration) {
+	if !ts.timeSkipping {
+		ts.fatal(errors.New("time skipping is not enabled, use the WithTimeSkipping option"))
+		return
+	}
+	ts.mu.Lock()
+	ts.timeOffset += d
+	ts.mu.Unlock()
+}
+
+// Sleep waits until no workflow task or activity of the workflows started by the
+// test clients is in flight, then advances the clock of the TestServer by d like
+// SkipTime. It requires the WithTimeSkipping option.
+//
+// Waiting first lets workflows reach the point where they block, with their
+// timers skipped, before the test goes on.
+func (ts *TestServer) Sleep(d time.Duration) {
+	if !ts.timeSkipping {
+		ts.fatal(errors.New("time skipping is not enabled, use the WithTimeSkipping option"))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := ts.waitIdle(ctx); err != nil {
+		ts.fatal(fmt.Errorf("error waiting for workflows: %w", err))
+		return
+	}
+	ts.SkipTime(d)
+}
+
+// waitIdle waits until the running workflows started by the test clients have no
+// workflow task or activity in flight.
+func (ts *TestServer) waitIdle(ctx context.Context) error {
+	for {
+		idle, err := ts.idle(ctx)
+		if err != nil || idle {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(idlePollInterval):
+		}
+	}
+}
+
+func (ts *TestServer) idle(ctx context.Context) (bool, error) {
+	ts.mu.Lock()
+	started := make(map[string][]string, len(ts.startedWorkflows))
+	for ns, ids := range ts.startedWorkflows {
+		started[ns] = append([]string(nil), ids...)
+	}
+	clients := make(map[string]client.Client, len(ts.namespaceClients))
+	for ns, c := range ts.namespaceClients {
+		clients[ns] = c
+	}
+	ts.mu.Unlock()
+
+	for ns, ids := range started {
+		for _, id := range ids {
+			resp, err := clients[ns].DescribeWorkflowExecution(ctx, id, "")
+			var notFound *serviceerror.NotFound
+			if errors.As(err, &notFound) {
+				continue
+			} else if err != nil {
+				return false, err
+			}
+			if resp.GetWorkflowExecutionInfo().GetStatus() != enums.WORKFLOW_EXECUTION_STATUS_RUNNING {
+				continue
+			}
+			if resp.GetPendingWorkflowTask() != nil || len(resp.GetPendingActivities()) > 0 {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func (ts *TestServer) currentTimeOffset() time.Duration {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return ts.timeOffset
+}
+
+func encodeTimeOffset(offset time.Duration) *commonpb.Payload {
+	// A Duration is always encodable
+	p, _ := converter.GetDefaultDataConverter().ToPayload(offset)
+	return p
+}
+
+// decodeTimeOffset returns the time offset of header, if any.
+func decodeTimeOffset(header map[string]*commonpb.Payload) (time.Duration, error) {
+	p, ok := header[timeOffsetHeader]
+	if !ok {
+		return 0, nil
+	}
+	var offset time.Duration
+	if err := converter.GetDefaultDataConverter().FromPayload(p, &offset); err != nil {
+		return 0, fmt.Errorf("invalid %s header: %w", timeOffsetHeader, err)
+	}
+	return offset, nil
+}
+
+// timeSkippingClientInterceptor passes the time skipped by SkipTime to the
+// workflows that the test clients start and signal. The header is recorded in the
+// existing events, so histories have the same events as without skipping.
+type timeSkippingClientInterceptor struct {
+	interceptor.ClientInterceptorBase
+	ts *TestServer
+}
+
+func (i *timeSkippingClientInterceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	return &timeSkippingClientOutbound{
+		ClientOutboundInterceptorBase: interceptor.ClientOutboundInterceptorBase{Next: next},
+		root:                          i,
+	}
+}
+
+type timeSkippingClientOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+	root *timeSkippingClientInterceptor
+}
+
+func (o *timeSkippingClientOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (client.WorkflowRun, error) {
+	o.setHeader(ctx)
+	return o.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (o *timeSkippingClientOutbound) SignalWorkflow(ctx context.Context, in *interceptor.ClientSignalWorkflowInput) error {
+	o.setHeader(ctx)
+	return o.Next.SignalWorkflow(ctx, in)
+}
+
+func (o *timeSkippingClientOutbound) SignalWithStartWorkflow(ctx context.Context, in *interceptor.ClientSignalWithStartWorkflowInput) (client.WorkflowRun, error) {
+	o.setHeader(ctx)
+	return o.Next.SignalWithStartWorkflow(ctx, in)
+}
+
+func (o *timeSkippingClientOutbound) setHeader(ctx context.Context) {
+	if header := interceptor.Header(ctx); header != nil {
+		header[timeOffsetHeader] = encodeTimeOffset(o.root.ts.currentTimeOffset())
+	}
+}
+
+// withTimeSkipping adds the time skipping interceptor to the worker options when
+// the WithTimeSkipping option is set.
+func (ts *TestServer) withTimeSkipping(opts worker.Options) worker.Options {
+	if ts.timeSkipping {
+		// Don't modify the caller's slice
+		opts.Interceptors = append(opts.Interceptors[:len(opts.Interceptors):len(opts.Interceptors)], &timeSkippingWorkerInterceptor{})
+	}
+	return opts
+}
+
+// timeSkippingWorkerInterceptor fires the timers of workflows without waiting for
+// their duration, and shifts the time seen by workflows accordingly, and by the
+// time skipped by SkipTime.
+//
+// Only the duration of the timers started on the server changes, which replay
+// ignores, so histories have the same events as without skipping. Workflows whose
+// commands and result do not depend on workflow.Now replay without the interceptor.
+type timeSkippingWorkerInterceptor struct {
+	interceptor.WorkerInterceptorBase
+}
+
+func (*timeSkippingWorkerInterceptor) InterceptWorkflow(ctx workflow.Context, next interceptor.WorkflowInboundInterceptor) interceptor.WorkflowInboundInterceptor {
+	return &timeSkippingWorkflowInbound{
+		WorkflowInboundInterceptorBase: interceptor.WorkflowInboundInterceptorBase{Next: next},
+	}
+}
+
+// timeSkippingWorkflowInbound holds the state of a single workflow execution. Like
+// workflow code, it is only accessed from one workflow goroutine at a time, and
+// only changes in response to workflow code and history events, so that it is
+// rebuilt identically on replay.
+type timeSkippingWorkflowInbound struct {
+	interceptor.WorkflowInboundInterceptorBase
+	outbound *timeSkippingWorkflowOutbound
+	// Time skipped so far, by timers or by SkipTime
+	offset time.Duration
+	// Number of activities, local activities and child workflows in flight
+	pending int
+	// Skipped timers that have not been released to workflow code, by deadline
+	timers []*skippedTimer
+}
+
+type skippedTimer struct {
+	deadline time.Time
+	settable workflow.Settable
+	fired    bool
+}
+
+func (w *timeSkippingWorkflowInbound) Init(outbound interceptor.WorkflowOutboundInterceptor) error {
+	w.outbound = &timeSkippingWorkflowOutbound{
+		WorkflowOutboundInterceptorBase: interceptor.WorkflowOutboundInterceptorBase{Next: outbound},
+		root:                            w,
+	}
+	return w.Next.Init(w.outbound)
+}
+
+func (w *timeSkippingWorkflowInbound) ExecuteWorkflow(ctx workflow.Context, in *interceptor.ExecuteWorkflowInput) (interface{}, error) {
+	if err := w.skipTo(interceptor.WorkflowHeader(ctx)); err != nil {
+		return nil, err
+	}
+	return w.Next.ExecuteWorkflow(ctx, in)
+}
+
+func (w *timeSkippingWorkflowInbound) HandleSignal(ctx workflow.Context, in *interceptor.HandleSignalInput) error {
+	if err := w.skipTo(interceptor.WorkflowHeader(ctx)); err != nil {
+		return err
+	}
+	return w.Next.HandleSignal(ctx, in)
+}
+
+// skipTo advances the time seen by the workflow to the time skipped by SkipTime
+// when the start or signal with header was sent, unless timers skipped more.
+func (w *timeSkippingWorkflowInbound) skipTo(header map[string]*commonpb.Payload) error {
+	offset, err := decodeTimeOffset(header)
+	if err != nil {
+		return err
+	}
+	if offset > w.offset {
+		w.offset = offset
+	}
+	return nil
+}
+
+// release sets the futures of the fired timers whose deadline is not after the
+// deadline of any timer still running, in deadline order, advancing the time seen
+// by the workflow to each deadline.
+func (w *timeSkippingWorkflowInbound) release(ctx workflow.Context) {
+	for len(w.timers) > 0 && w.timers[0].fired {
+		t := w.timers[0]
+		w.timers = w.timers[1:]
+		if skipped := t.deadline.Sub(w.outbound.Next.Now(ctx)); skipped > w.offset {
+			w.offset = skipped
+		}
+		t.settable.Set(nil, nil)
+	}
+}
+
+func (w *timeSkippingWorkflowInbound) remove(t *skippedTimer) {
+	for i, e := range w.timers {
+		if e == t {
+			w.timers = append(w.timers[:i], w.timers[i+1:]...)
+			return
+		}
+	}
+}
+
+type timeSkippingWorkflowOutbound struct {
+	interceptor.WorkflowOutboundInterceptorBase
+	root *timeSkippingWorkflowInbound
+}
+
+func (o *timeSkippingWorkflowOutbound) Now(ctx workflow.Context) time.Time {
+	return o.Next.Now(ctx).Add(o.root.offset)
+}
+
+// NewTimer starts a server timer of skippedTimerDuration instead of d when no
+// activity or child workflow is in flight. Otherwise the timer runs on the wall
+// clock, as it may be meant to expire after what is in flight completes.
+func (o *timeSkippingWorkflowOutbound) NewTimer(ctx workflow.Context, d time.Duration) workflow.Future {
+	if d <= skippedTimerDuration || o.root.pending > 0 {
+		return o.Next.NewTimer(ctx, d)
+	}
+
+	timer := o.Next.NewTimer(ctx, skippedTimerDuration)
+	future, settable := workflow.NewFuture(ctx)
+	t := &skippedTimer{
+		deadline: o.Now(ctx).Add(d),
+		settable: settable,
+	}
+	i := len(o.root.timers)
+	for i > 0 && o.root.timers[i-1].deadline.After(t.deadline) {
+		i--
+	}
+	o.root.timers = append(o.root.timers[:i], append([]*skippedTimer{t}, o.root.timers[i:]...)...)
+
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		if err := timer.Get(ctx, nil); err != nil {
+			// The timer was canceled
+			o.root.remove(t)
+			settable.Set(nil, err)
+			o.root.release(ctx)
+			return
+		}
+		t.fired = true
+		o.root.release(ctx)
+	})
+	return future
+}
+
+func (o *timeSkippingWorkflowOutbound) Sleep(ctx workflow.Context, d time.Duration) error {
+	return o.NewTimer(ctx, d).Get(ctx, nil)
+}
+
+func (o *timeSkippingWorkflowOutbound) ExecuteActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	return o.track(ctx, o.Next.ExecuteActivity(ctx, activityType, args...))
+}
+
+func (o *timeSkippingWorkflowOutbound) ExecuteLocalActivity(ctx workflow.Context, activityType string, args ...interface{}) workflow.Future {
+	return o.track(ctx, o.Next.ExecuteLocalActivity(ctx, activityType, args...))
+}
+
+func (o *timeSkippingWorkflowOutbound) ExecuteChildWorkflow(ctx workflow.Context, childWorkflowType string, args ...interface{}) workflow.ChildWorkflowFuture {
+	o.setHeader(ctx)
+	future := o.Next.ExecuteChildWorkflow(ctx, childWorkflowType, args...)
+	o.track(ctx, future)
+	return future
+}
+
+func (o *timeSkippingWorkflowOutbound) NewContinueAsNewError(ctx workflow.Context, wfn interface{}, args ...interface{}) error {
+	o.setHeader(ctx)
+	return o.Next.NewContinueAsNewError(ctx, wfn, args...)
+}
+
+// setHeader passes the time skipped so far to the workflow started with ctx.
+func (o *timeSkippingWorkflowOutbound) setHeader(ctx workflow.Context) {
+	if header := interceptor.WorkflowHeader(ctx); header != nil {
+		header[timeOffsetHeader] = encodeTimeOffset(o.root.offset)
+	}
+}
+
+// track counts future as in flight until it is ready.
+func (o *timeSkippingWorkflowOutbound) track(ctx workflow.Context, future workflow.Future) workflow.Future {
+	o.root.pending++
+	workflow.Go(ctx, func(ctx workflow.Context) {
+		_ = future.Get(ctx, nil)
+		o.root.pending--
+	})
+	return future
+}
@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporaltest
+
+import (
+	"context"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/interceptor"
+)
+
+func (ts *TestServer) trackWorkflow(namespace, workflowID string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.startedWorkflows == nil {
+		ts.startedWorkflows = make(map[string][]string)
+	}
+	ts.startedWorkflows[namespace] = append(ts.startedWorkflows[namespace], workflowID)
+}
+
+// trackingClientInterceptor records the workflows started by the test clients, so
+// that they are replayed by the replay check, and terminated when the test of a
+// shared server completes, even before the visibility store lists them.
+type trackingClientInterceptor struct {
+	interceptor.ClientInterceptorBase
+	ts        *TestServer
+	namespace string
+}
+
+func (i *trackingClientInterceptor) InterceptClient(next interceptor.ClientOutboundInterceptor) interceptor.ClientOutboundInterceptor {
+	return &trackingClientOutbound{
+		ClientOutboundInterceptorBase: interceptor.ClientOutboundInterceptorBase{Next: next},
+		root:                          i,
+	}
+}
+
+type trackingClientOutbound struct {
+	interceptor.ClientOutboundInterceptorBase
+	root *trackingClientInterceptor
+}
+
+func (o *trackingClientOutbound) ExecuteWorkflow(ctx context.Context, in *interceptor.ClientExecuteWorkflowInput) (client.WorkflowRun, error) {
+	run, err := o.Next.ExecuteWorkflow(ctx, in)
+	if err == nil {
+		o.root.ts.trackWorkflow(o.root.namespace, run.GetID())
+	}
+	return run, err
+}
+
+func (o *trackingClientOutbound) SignalWithStartWorkflow(ctx context.Context, in *interceptor.ClientSignalWithStartWorkflowInput) (client.WorkflowRun, error) {
+	run, err := o.Next.SignalWithStartWorkflow(ctx, in)
+	if err == nil {
+		o.root.ts.trackWorkflow(o.root.namespace, run.GetID())
+	}
+	return run, err
+}
@@ -0,0 +1,162 @@
+// Unless explicitly stated otherwise all files in this repository are licensed under the MIT License.
+//
+// This product includes software developed at Datadog (https://www.datadoghq.com/). Copyright 2021 Datadog, Inc.
+
+package temporalite
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"go.temporal.io/server/common/log/tag"
+
+	"github.com/temporalio/temporalite/internal/liteconfig"
+)
+
+// UIFailurePolicy determines how the server reacts when the UI server fails to start.
+type UIFailurePolicy = liteconfig.UIFailurePolicy
+
+const (
+	// UIFailFast makes Start return an error when the UI server fails to start.
+	UIFailFast = liteconfig.UIFailFast
+	// UILogAndContinue logs UI server failures and keeps Temporal running headless.
+	UILogAndContinue = liteconfig.UILogAndContinue
+	// UIRetryOnAnotherPort retries starting the UI server once on a system-chosen
+	// port, then behaves like UILogAndContinue.
+	//
+	// The UI server must support changing its port; the UI server used by the
+	// temporalite CLI does.
+	UIRetryOnAnotherPort = liteconfig.UIRetryOnAnotherPort
+)
+
+const (
+	// How long to wait for a UI server to report an error before assuming it has
+	// started.
+	uiStartGracePeriod = time.Second
+	// How long to wait for a UI server that can be probed to accept connections.
+	uiStartTimeout = 10 * time.Second
+)
+
+// startUI starts the UI server, applying the configured failure policy if it
+// cannot be started.
+func (s *Server) startUI() error {
+	err := s.runUI()
+	if err == nil {
+		return nil
+	}
+
+	switch s.config.UIFailurePolicy {
+	case UIRetryOnAnotherPort:
+		if err = s.restartUIOnAnotherPort(err); err == nil {
+			return nil
+		}
+	case UILogAndContinue:
+	default:
+		return fmt.Errorf("unable to start UI server: %w", err)
+	}
+
+	s.config.Logger.Error("Unable to start UI server, continuing without UI", tag.Error(err))
+	s.ui = liteconfig.NoopUIServer{}
+	return nil
+}
+
+func (s *Server) restartUIOnAnotherPort(startErr error) error {
+	rebindable, ok := s.ui.(liteconfig.RebindableUIServer)
+	if !ok {
+		return fmt.Errorf("%w (UI server does not support changing ports)", startErr)
+	}
+
+	portProvider := liteconfig.NewPortProvider()
+	port, err := portProvider.GetFreePort()
+	if closeErr := portProvider.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w (unable to find a free port: %v)", startErr, err)
+	}
+
+	ui, err := rebindable.WithPort(port)
+	if err != nil {
+		return fmt.Errorf("%w (unable to configure UI server on port %d: %v)", startErr, port, err)
+	}
+	s.config.Logger.Warn("Unable to start UI server, retrying on another port", tag.Error(startErr), tag.Port(port))
+	s.ui = ui
+
+	return s.runUI()
+}
+
+// runUI starts the UI server in the background and waits until it is accepting
+// connections or has failed to start.
+//
+// UI servers that do not implement liteconfig.AddressableUIServer are assumed to
+// have started if they do not return an error within uiStartGracePeriod.
+func (s *Server) runUI() error {
+	ui := s.ui
+	addressable, ok := ui.(liteconfig.AddressableUIServer)
+	// The readiness probe cannot tell the UI server apart from another process
+	// already listening on its address
+	if ok && accepting(addressable.Addr()) {
+		return fmt.Errorf("address %s already in use", addressable.Addr())
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- ui.Start()
+	}()
+
+	if !ok {
+		return s.awaitUIErrors(errCh)
+	}
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	timeout := time.After(uiStartTimeout)
+	for {
+		if accepting(addressable.Addr()) {
+			// Another process may have started listening on the address since it
+			// was checked, in which case the UI server fails to bind shortly
+			return s.awaitUIErrors(errCh)
+		}
+		select {
+		case err := <-errCh:
+			if err == nil {
+				err = fmt.Errorf("UI server exited without listening on %s", addressable.Addr())
+			}
+			return err
+		case <-timeout:
+			ui.Stop()
+			return fmt.Errorf("UI server not accepting connections on %s after %s", addressable.Addr(), uiStartTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// awaitUIErrors returns the error reported by the UI server within
+// uiStartGracePeriod, or logs errors reported after that.
+func (s *Server) awaitUIErrors(errCh <-chan error) error {
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(uiStartGracePeriod):
+		go s.logUIErrors(errCh)
+		return nil
+	}
+}
+
+// accepting reports whether addr accepts TCP connections.
+func accepting(addr string) bool {
+	c, err := net.DialTimeout("tcp", addr, readinessProbeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = c.Close()
+	return true
+}
+
+// logUIErrors reports errors returned by the UI server after it has started.
+func (s *Server) logUIErrors(errCh <-chan error) {
+	if err := <-errCh; err != nil {
+		s.config.Logger.Error("UI server stopped unexpectedly", tag.Error(err))
+	}
+}